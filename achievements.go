@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// playerAchievementsResponse represents the response of ISteamUserStats/GetPlayerAchievements.
+type playerAchievementsResponse struct {
+	PlayerStats struct {
+		Success      bool   `json:"success"`
+		Error        string `json:"error"`
+		Achievements []struct {
+			APIName  string `json:"apiname"`
+			Achieved int    `json:"achieved"`
+		} `json:"achievements"`
+	} `json:"playerstats"`
+}
+
+// GameWithAchievements is a Game together with the user's achievement progress in it.
+type GameWithAchievements struct {
+	Game
+	AchievementsTotal    int
+	AchievementsUnlocked int
+}
+
+// fetchAchievementProgress returns how many achievements the user has unlocked in a game
+// and how many the game has in total. Games without achievements report zero for both.
+// Arguments:
+//   - ctx: The context for the request.
+//   - client: The HTTP client used for the request.
+//   - apiKey: The Steam API key to authenticate the request.
+//   - steamID64: The user's SteamID64.
+//   - appID: The app ID of the game.
+// Returns the unlocked and total achievement counts and an error if the request fails
+// or the profile's game details are private.
+func fetchAchievementProgress(ctx context.Context, client *http.Client, apiKey, steamID64 string, appID int) (unlocked, total int, err error) {
+	apiURL := steamAPI.endpoint("ISteamUserStats/GetPlayerAchievements/v1/", url.Values{
+		"key":     {apiKey},
+		"steamid": {steamID64},
+		"appid":   {strconv.Itoa(appID)},
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("fetching achievements: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Steam answers 400 with a JSON error body for games that have no stats at all.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return 0, 0, fmt.Errorf("Steam API returned %s", resp.Status)
+	}
+
+	var apiResp playerAchievementsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return 0, 0, fmt.Errorf("invalid response from Steam API: %w", err)
+	}
+	if !apiResp.PlayerStats.Success {
+		if apiResp.PlayerStats.Error == "Requested app has no stats" {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("no achievements for app %d: %s", appID, apiResp.PlayerStats.Error)
+	}
+
+	for _, achievement := range apiResp.PlayerStats.Achievements {
+		if achievement.Achieved == 1 {
+			unlocked++
+		}
+	}
+	return unlocked, len(apiResp.PlayerStats.Achievements), nil
+}
+
+// fetchAchievementCount returns how many achievements the user has unlocked in a game.
+// Games without achievements report zero unlocked achievements.
+// Arguments:
+//   - ctx: The context for the request.
+//   - client: The HTTP client used for the request.
+//   - apiKey: The Steam API key to authenticate the request.
+//   - steamID64: The user's SteamID64.
+//   - appID: The app ID of the game.
+// Returns the number of unlocked achievements and an error if the request fails
+// or the profile's game details are private.
+func fetchAchievementCount(ctx context.Context, client *http.Client, apiKey, steamID64 string, appID int) (int, error) {
+	unlocked, _, err := fetchAchievementProgress(ctx, client, apiKey, steamID64, appID)
+	return unlocked, err
+}
+
+// fetchAllAchievementProgress fetches the achievement progress of every game,
+// at most perGameRateLimit requests per second.
+// Games whose achievements cannot be fetched are logged and left out of the result.
+// Arguments:
+//   - ctx: The context bounding all requests.
+//   - client: The HTTP client used for the requests.
+//   - apiKey: The Steam API key.
+//   - steamID64: The user's SteamID64.
+//   - games: The games to fetch achievements for.
+// Returns the games with their progress in their original order and an error if the context is cancelled.
+func fetchAllAchievementProgress(ctx context.Context, client *http.Client, apiKey, steamID64 string, games []Game) ([]GameWithAchievements, error) {
+	progress := make([]GameWithAchievements, 0, len(games))
+	limiter := NewRateLimiter(perGameRateLimit)
+	defer limiter.Stop()
+
+	for i, game := range games {
+		if err := limiter.Wait(ctx); err != nil {
+			return progress, err
+		}
+		infof(os.Stderr, "\rFetching achievements %d/%d...", i+1, len(games))
+		unlocked, total, err := fetchAchievementProgress(ctx, client, apiKey, steamID64, game.AppID)
+		if err != nil {
+			loggerFrom(ctx).Warn("skipping game", "game", game.Name, "err", err)
+			continue
+		}
+		progress = append(progress, GameWithAchievements{Game: game, AchievementsTotal: total, AchievementsUnlocked: unlocked})
+	}
+	infof(os.Stderr, "\n")
+	return progress, nil
+}
+
+// fetchAllAchievementCounts fetches the unlocked achievement count of every game,
+// at most perGameRateLimit requests per second.
+// Games whose achievements cannot be fetched are logged and left out of the result.
+// Arguments:
+//   - ctx: The context bounding all requests.
+//   - client: The HTTP client used for the requests.
+//   - apiKey: The Steam API key.
+//   - steamID64: The user's SteamID64.
+//   - games: The games to fetch achievements for.
+// Returns the unlocked counts keyed by app ID and an error if the context is cancelled.
+func fetchAllAchievementCounts(ctx context.Context, client *http.Client, apiKey, steamID64 string, games []Game) (map[int]int, error) {
+	progress, err := fetchAllAchievementProgress(ctx, client, apiKey, steamID64, games)
+	counts := make(map[int]int, len(progress))
+	for _, game := range progress {
+		counts[game.AppID] = game.AchievementsUnlocked
+	}
+	return counts, err
+}
+
+// getAlmostCompletedGames returns the games in which at least thresholdPercent of the achievements
+// are unlocked. Games without achievements are left out.
+// Arguments:
+//   - games: The games with their achievement progress.
+//   - thresholdPercent: The minimum unlocked fraction, from 0.0 to 1.0.
+// Returns the matching games in their original order.
+func getAlmostCompletedGames(games []GameWithAchievements, thresholdPercent float64) []GameWithAchievements {
+	almost := make([]GameWithAchievements, 0)
+	for _, game := range games {
+		if game.AchievementsTotal == 0 {
+			continue
+		}
+		if float64(game.AchievementsUnlocked)/float64(game.AchievementsTotal) >= thresholdPercent {
+			almost = append(almost, game)
+		}
+	}
+	return almost
+}
+
+// filterNoAchievements returns the games in which the user has not unlocked any achievement.
+// Games without a known count are left out.
+// Arguments:
+//   - games: The games to filter.
+//   - counts: The unlocked achievement counts keyed by app ID.
+// Returns the games with zero unlocked achievements in their original order.
+func filterNoAchievements(games []Game, counts map[int]int) []Game {
+	filtered := make([]Game, 0)
+	for _, game := range games {
+		if count, ok := counts[game.AppID]; ok && count == 0 {
+			filtered = append(filtered, game)
+		}
+	}
+	return filtered
+}
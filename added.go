@@ -0,0 +1,83 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// appIDMilestone ties an app ID to the approximate date the game became available on Steam.
+type appIDMilestone struct {
+	AppID int
+	Date  time.Time
+}
+
+// appIDMilestones lists well-known games in app ID order with their (approximate) Steam release
+// dates. Steam hands out app IDs roughly chronologically, so interpolating between these points
+// gives a rough date for any app ID. It is only a rough guide: app IDs are reserved months or
+// years before release, and some low IDs were reused or assigned out of order.
+var appIDMilestones = []appIDMilestone{
+	{0, time.Date(2003, 9, 12, 0, 0, 0, 0, time.UTC)},        // Steam launch
+	{220, time.Date(2004, 11, 16, 0, 0, 0, 0, time.UTC)},     // Half-Life 2
+	{400, time.Date(2007, 10, 10, 0, 0, 0, 0, time.UTC)},     // Portal
+	{35140, time.Date(2009, 9, 15, 0, 0, 0, 0, time.UTC)},    // Batman: Arkham Asylum
+	{72850, time.Date(2011, 11, 11, 0, 0, 0, 0, time.UTC)},   // The Elder Scrolls V: Skyrim
+	{218620, time.Date(2013, 8, 13, 0, 0, 0, 0, time.UTC)},   // PAYDAY 2
+	{250900, time.Date(2014, 11, 4, 0, 0, 0, 0, time.UTC)},   // The Binding of Isaac: Rebirth
+	{292030, time.Date(2015, 5, 18, 0, 0, 0, 0, time.UTC)},   // The Witcher 3
+	{413150, time.Date(2016, 2, 26, 0, 0, 0, 0, time.UTC)},   // Stardew Valley
+	{504230, time.Date(2018, 1, 25, 0, 0, 0, 0, time.UTC)},   // Celeste
+	{1145360, time.Date(2019, 12, 10, 0, 0, 0, 0, time.UTC)}, // Hades (Early Access)
+	{1245620, time.Date(2022, 2, 25, 0, 0, 0, 0, time.UTC)},  // Elden Ring
+	{2050650, time.Date(2023, 3, 24, 0, 0, 0, 0, time.UTC)},  // Resident Evil 4 (2023)
+	{2694490, time.Date(2024, 12, 6, 0, 0, 0, 0, time.UTC)},  // Path of Exile 2 (Early Access)
+}
+
+// estimateAddedDate estimates when a game became available on Steam from its app ID,
+// interpolating linearly between appIDMilestones. App IDs beyond the last milestone are
+// extrapolated with the slope of the last segment, but never into the future.
+// Arguments:
+//   - appID: The app ID of the game.
+// Returns the estimated date.
+func estimateAddedDate(appID int) time.Time {
+	i := sort.Search(len(appIDMilestones), func(i int) bool {
+		return appIDMilestones[i].AppID > appID
+	})
+	switch {
+	case i == 0:
+		return appIDMilestones[0].Date
+	case i == len(appIDMilestones):
+		// Past the table: keep going with the slope of the last segment.
+		i = len(appIDMilestones) - 1
+	}
+	lo, hi := appIDMilestones[i-1], appIDMilestones[i]
+	fraction := float64(appID-lo.AppID) / float64(hi.AppID-lo.AppID)
+	offset := fraction * float64(hi.Date.Sub(lo.Date))
+	// Compare before converting: a far-off extrapolation would overflow time.Duration.
+	now := time.Now()
+	if offset >= float64(now.Sub(lo.Date)) {
+		return now
+	}
+	return lo.Date.Add(time.Duration(offset))
+}
+
+// listRecentlyAdded returns the games that were probably added to the library in the last days.
+// Steam does not report purchase dates, so this uses estimateAddedDate: a game that only came out
+// recently must also have been bought recently. The reverse does not hold, so older games bought
+// recently are missed, and the dates themselves are only accurate to a few months.
+// Arguments:
+//   - games: The games to filter.
+//   - days: How many days back to look.
+// Returns the matching games, newest estimate first.
+func listRecentlyAdded(games []Game, days int) []Game {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	recent := make([]Game, 0)
+	for _, game := range games {
+		if estimateAddedDate(game.AppID).After(cutoff) {
+			recent = append(recent, game)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].AppID > recent[j].AppID
+	})
+	return recent
+}
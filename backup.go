@@ -0,0 +1,129 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// getConfigDir returns the directory holding the configuration file and the saved profiles.
+// It uses the user's home directory (or --config-path) and a fixed path ".wsipn".
+// Arguments:
+//   - None
+// Returns the directory path and an error if the home directory cannot be determined.
+func getConfigDir() (string, error) {
+	home, err := storage.homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".wsipn"), nil
+}
+
+// backupProfiles writes a ZIP archive of every file under configDir to destPath.
+// The archive may contain the API key, so it is created with permissions 0600.
+// Arguments:
+//   - configDir: The directory to back up, usually ~/.wsipn.
+//   - destPath: The archive to create; an existing file is replaced.
+// Returns an error if configDir cannot be read or the archive cannot be written.
+func backupProfiles(configDir, destPath string) error {
+	if _, err := os.Stat(configDir); err != nil {
+		return fmt.Errorf("nothing to back up: %w", err)
+	}
+	out, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+
+	err = filepath.WalkDir(configDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(configDir, path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(w, in)
+		return err
+	})
+	if err != nil {
+		zw.Close()
+		out.Close()
+		return fmt.Errorf("writing backup: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return fmt.Errorf("writing backup: %w", err)
+	}
+	return out.Close()
+}
+
+// restoreProfiles extracts a ZIP archive written by backupProfiles into configDir,
+// replacing files with the same name. Entries that would end up outside configDir are refused.
+// Restored files get permissions 0600 and directories 0700.
+// Arguments:
+//   - srcPath: The archive to restore.
+//   - configDir: The directory to restore into, usually ~/.wsipn.
+// Returns an error if the archive cannot be read or a file cannot be written.
+func restoreProfiles(srcPath, configDir string) error {
+	zr, err := zip.OpenReader(srcPath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		name := filepath.FromSlash(f.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("refusing to restore %q outside the config directory", f.Name)
+		}
+		path := filepath.Join(configDir, name)
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0700); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		if err := extractZipFile(f, path); err != nil {
+			return fmt.Errorf("restoring %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// extractZipFile writes the content of one archive entry to path.
+// Arguments:
+//   - f: The archive entry.
+//   - path: The file to write.
+// Returns an error if the entry cannot be read or the file cannot be written.
+func extractZipFile(f *zip.File, path string) error {
+	in, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+)
+
+// betaMarkers are the name fragments that mark a beta branch, playtest or tool
+// listed among the owned games. Matching is case-sensitive so that names such as
+// "Alphabet" are not caught.
+var betaMarkers = []string{"Beta", "Playtest", "Test App"}
+
+// isBetaEntry reports whether a library entry looks like a beta, playtest or test app by its name.
+// Arguments:
+//   - name: The name of the game.
+// Returns true if the name contains one of betaMarkers.
+func isBetaEntry(name string) bool {
+	for _, marker := range betaMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeBetaEntries returns the games that do not look like beta or test entries.
+// Games listed in allowed are always kept, so real games with "Beta" in their name can be rescued.
+// Arguments:
+//   - games: The games to filter.
+//   - allowed: The game names to keep regardless of isBetaEntry, matched exactly ignoring case.
+// Returns the remaining games in their original order.
+func removeBetaEntries(games []Game, allowed []string) []Game {
+	keep := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		keep[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	kept := make([]Game, 0, len(games))
+	for _, game := range games {
+		if !isBetaEntry(game.Name) || keep[strings.ToLower(game.Name)] {
+			kept = append(kept, game)
+		}
+	}
+	return kept
+}
+
+// loadBetaAllowFile reads the game names listed in ~/.wsipn_beta_allow, one per line.
+// Blank lines are ignored.
+// Arguments:
+//   - None
+// Returns the allowed names and an error if the file cannot be read.
+func loadBetaAllowFile() ([]string, error) {
+	return loadNameListFile(".wsipn_beta_allow")
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// gameCache is the on-disk representation of the cached game list.
+type gameCache struct {
+	SavedAt time.Time `json:"saved_at"`
+	Games   []Game    `json:"games"`
+}
+
+// getCacheFilePath returns the file path where the game list cache of a profile is stored.
+// The default profile uses ".wsipn_cache.json" in the user's home directory (or --config-path),
+// other profiles use ".wsipn_cache_<profile>.json" so their libraries never mix.
+// Arguments:
+//   - profile: The profile name.
+// Returns the file path as a string and an error if the home directory cannot be determined.
+func getCacheFilePath(profile string) (string, error) {
+	home, err := storage.homeDir()
+	if err != nil {
+		return "", err
+	}
+	if profile == "default" {
+		return filepath.Join(home, ".wsipn_cache.json"), nil
+	}
+	return filepath.Join(home, ".wsipn_cache_"+profile+".json"), nil
+}
+
+// deleteCache removes the profile's cache file, e.g. after logging in with another account.
+// A missing cache file is ignored and other failures are only logged.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - profile: The profile name.
+func deleteCache(ctx context.Context, profile string) {
+	path, err := getCacheFilePath(profile)
+	if err != nil {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		loggerFrom(ctx).Warn("could not delete game cache", "err", err)
+	}
+}
+
+// loadCache reads a cached game list from the given file.
+// Arguments:
+//   - path: The cache file to read.
+// Returns the cached games, the time they were saved and an error if the file cannot be read or decoded.
+func loadCache(path string) ([]Game, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var cache gameCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid cache file: %w", err)
+	}
+	return cache.Games, cache.SavedAt, nil
+}
+
+// saveCache writes the given games to the cache file together with the current time.
+// The file is created with permissions 0600 (read/write for the owner only).
+// Arguments:
+//   - path: The cache file to write.
+//   - games: The games to cache.
+// Returns an error if the games cannot be encoded or the file cannot be written.
+func saveCache(path string, games []Game) error {
+	data, err := json.Marshal(gameCache{SavedAt: time.Now(), Games: games})
+	if err != nil {
+		return fmt.Errorf("encoding cache: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// listGamesCached returns the user's games from the cache file if it is younger than ttl,
+// and otherwise fetches them from the Steam API and refreshes the cache.
+// A ttl of 0 disables the cache entirely.
+// Arguments:
+//   - ctx: The context for the request.
+//   - client: The SteamClient used when the cache cannot be used.
+//   - profile: The profile whose cache file is used.
+//   - steamID64: The user's SteamID64.
+//   - ttl: The maximum age of a cache entry that may be reused.
+// Returns the games and an error if they cannot be fetched from Steam.
+func listGamesCached(ctx context.Context, client SteamClient, profile, steamID64 string, ttl time.Duration) ([]Game, error) {
+	if ttl <= 0 {
+		return listGames(ctx, client, steamID64)
+	}
+	path, err := getCacheFilePath(profile)
+	if err != nil {
+		return listGames(ctx, client, steamID64)
+	}
+	if games, savedAt, err := loadCache(path); err == nil && time.Since(savedAt) < ttl {
+		infof(os.Stdout, "Using cached game list from %s\n", savedAt.Format(time.RFC1123))
+		return games, nil
+	}
+
+	games, err := listGames(ctx, client, steamID64)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveCache(path, games); err != nil {
+		loggerFrom(ctx).Warn("could not save game cache", "err", err)
+	}
+	return games, nil
+}
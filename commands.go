@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// newRootCommand builds the wsipn command tree. Without a subcommand the root
+// command keeps the original flag-only behavior, so existing invocations still work.
+// All commands share the flags defined by newFlagSet, before or after the subcommand.
+// Arguments:
+//   - None
+// Returns the root command.
+func newRootCommand() *cobra.Command {
+	root := flagCommand("wsipn", "Suggest an unplayed game from your Steam library", 0, run)
+	root.Long = "wsipn (What Should I Play Next) suggests a random unplayed game from your Steam library.\n" +
+		"Run it without a subcommand to pick a game, or use one of the subcommands below.\n" +
+		"Run a command with --help to list its flags."
+	// The values stored here are discarded; flagCommand hands the flags that were set to parseFlags.
+	root.PersistentFlags().AddGoFlagSet(newFlagSet(&options{}))
+	root.AddCommand(
+		flagCommand("login", "Log in to Steam and save the SteamID64 for the profile", 0, runLogin),
+		flagCommand("logout", "Forget the saved SteamID64 and game cache of the profile", 0, runLogout),
+		flagCommand("list", "List every game in the library with its playtime", 0, runList),
+		flagCommand("pick", "Suggest a random unplayed game (the default)", 0, run),
+		flagCommand("stats", "Print playtime statistics for the library", 0, runStats),
+		flagCommand("compare [steamid64]", "Compare the library with another account's", 1, runCompare),
+		flagCommand("backup <file>", "Write the config and saved profiles to a ZIP archive", 1, runBackup),
+		flagCommand("restore <file>", "Restore the config and saved profiles from a ZIP archive", 1, runRestore),
+	)
+	return root
+}
+
+// flagCommand creates a command whose flags, once parsed by cobra, are validated by parseFlags,
+// so every command accepts the same flags with the same checks.
+// Arguments:
+//   - use: The command name, followed by its positional arguments.
+//   - short: The one-line description shown in help output.
+//   - maxArgs: The number of positional arguments the command accepts.
+//   - runFunc: The function to run with the parsed options and a context carrying the logger.
+// Returns the command.
+func flagCommand(use, short string, maxArgs int, runFunc func(ctx context.Context, opts options) error) *cobra.Command {
+	return &cobra.Command{
+		Use:           use,
+		Short:         short,
+		Args:          cobra.ArbitraryArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := parseFlags(append(flagArgs(cmd), append([]string{"--"}, args...)...))
+			if err != nil {
+				return fmt.Errorf("invalid arguments: %w", err)
+			}
+			if len(opts.args) > maxArgs {
+				return fmt.Errorf("invalid arguments: unexpected argument %q", opts.args[maxArgs])
+			}
+			ctx := withLogger(cmd.Context(), configureRuntime(opts))
+			return runFunc(ctx, opts)
+		},
+	}
+}
+
+// flagArgs returns the flags set on the command line of cmd as --name=value arguments for parseFlags.
+// Arguments:
+//   - cmd: The command whose flags cobra has parsed.
+// Returns the arguments in flag name order.
+func flagArgs(cmd *cobra.Command) []string {
+	var args []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name != "help" {
+			args = append(args, "--"+f.Name+"="+f.Value.String())
+		}
+	})
+	return args
+}
+
+// runLogin performs a fresh OpenID login for the profile, replacing any saved SteamID64.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - opts: The parsed command-line options.
+// Returns an error if the login fails.
+func runLogin(ctx context.Context, opts options) error {
+	if opts.noSave {
+		return errors.New("login only saves the SteamID64 and cannot be combined with --no-save")
+	}
+	// The API key is only needed for the welcome message, so logging in works without one.
+	_, err := loginAndSave(ctx, opts, loadAPIKey(ctx, opts.apiKey))
+	return err
+}
+
+// runLogout deletes the profile's saved SteamID64 and game cache.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - opts: The parsed command-line options.
+// Returns an error if the saved SteamID64 could not be deleted.
+func runLogout(ctx context.Context, opts options) error {
+	if err := deleteSteamID64(opts.profile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not delete saved SteamID64: %w", err)
+	}
+	deleteCache(ctx, opts.profile)
+	fmt.Printf("✔️ Logged out of profile %q.\n", opts.profile)
+	return nil
+}
+
+// runList prints every owned game with its total playtime, ordered by --sort-by.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - opts: The parsed command-line options.
+// Returns an error if the library could not be fetched.
+func runList(ctx context.Context, opts options) error {
+	apiKey, err := requireAPIKey(ctx, opts)
+	if err != nil {
+		return err
+	}
+	steamID64, err := resolveSteamID(ctx, opts, apiKey, false)
+	if err != nil {
+		return err
+	}
+	games, err := listGamesCached(ctx, NewHTTPSteamClient(httpClient, apiKey), opts.profile, steamID64, effectiveCacheTTL(opts))
+	if err != nil {
+		return fmt.Errorf("could not list games: %w", err)
+	}
+	games, err = sortGames(games, opts.sortBy)
+	if err != nil {
+		return err
+	}
+	for _, game := range games {
+		fmt.Printf("%s (%s)\n", game.Name, formatPlaytime(game.PlaytimeForever, opts.playtimeUnit))
+	}
+	return nil
+}
+
+// runStats prints playtime statistics, like --stats-only.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - opts: The parsed command-line options.
+// Returns an error if the statistics could not be computed.
+func runStats(ctx context.Context, opts options) error {
+	opts.statsOnly = true
+	return run(ctx, opts)
+}
+
+// runCompare compares the library with the account given as argument or via --compare-steam-id.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - opts: The parsed command-line options.
+// Returns an error if no account to compare with was given or the comparison fails.
+func runCompare(ctx context.Context, opts options) error {
+	if len(opts.args) > 0 {
+		opts.compareSteamID = opts.args[0]
+	}
+	if opts.compareSteamID == "" {
+		return errors.New("compare needs a SteamID64, as argument or via --compare-steam-id")
+	}
+	if err := validateSteamID64(opts.compareSteamID); err != nil {
+		return fmt.Errorf("invalid SteamID64 to compare with: %w", err)
+	}
+	return run(ctx, opts)
+}
+
+// runBackup writes a backup of the config directory to the file given as argument.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - opts: The parsed command-line options.
+// Returns an error if no file was given or the backup fails.
+func runBackup(ctx context.Context, opts options) error {
+	if len(opts.args) == 0 {
+		return errors.New("backup needs the path of the archive to write, e.g. wsipn backup wsipn.zip")
+	}
+	dir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := backupProfiles(dir, opts.args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("✔️ Backed up %s to %s\n", dir, opts.args[0])
+	return nil
+}
+
+// runRestore restores the config directory from the archive given as argument.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - opts: The parsed command-line options.
+// Returns an error if no archive was given or the restore fails.
+func runRestore(ctx context.Context, opts options) error {
+	if len(opts.args) == 0 {
+		return errors.New("restore needs the path of an archive written by backup, e.g. wsipn restore wsipn.zip")
+	}
+	dir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := restoreProfiles(opts.args[0], dir); err != nil {
+		return err
+	}
+	fmt.Printf("✔️ Restored %s from %s\n", dir, opts.args[0])
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+)
+
+// Config represents the optional configuration file stored in ~/.wsipn/config.json.
+type Config struct {
+	SteamAPIKey string `json:"steam_api_key"`
+}
+
+// StorageConfig describes where wsipn keeps its persistent files.
+type StorageConfig struct {
+	// BaseDir replaces the user's home directory as the base of every file path.
+	// Empty means the home directory.
+	BaseDir string
+}
+
+// storage is the storage configuration used by all file paths.
+// It is set from the --config-path flag in configureRuntime.
+var storage StorageConfig
+
+// homeDir returns the directory that the wsipn files are stored in.
+// Arguments:
+//   - None
+// Returns BaseDir if set, otherwise the user's home directory, and an error if the home directory cannot be determined.
+func (c StorageConfig) homeDir() (string, error) {
+	if c.BaseDir != "" {
+		return c.BaseDir, nil
+	}
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return usr.HomeDir, nil
+}
+
+// getConfigFilePath returns the file path of the configuration file.
+// It uses the user's home directory (or --config-path) and a fixed path ".wsipn/config.json".
+// Arguments:
+//   - None
+// Returns the file path as a string and an error if the home directory cannot be determined.
+func getConfigFilePath() (string, error) {
+	home, err := storage.homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".wsipn", "config.json"), nil
+}
+
+// loadConfig reads and decodes the configuration file at the given path.
+// Arguments:
+//   - path: The configuration file to read.
+// Returns the configuration and an error if the file cannot be read or decoded.
+func loadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// loadAPIKey returns the Steam API key from the first source that provides one,
+// in order: the --api-key flag, the STEAM_API_KEY environment variable,
+// the .env file in the working directory, the system keychain and the configuration file.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - flagValue: The value of the --api-key flag.
+// Returns the API key, or an empty string if no source provides one.
+func loadAPIKey(ctx context.Context, flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	// godotenv never overrides variables that are already set,
+	// so the real environment takes precedence over the .env file.
+	_ = godotenv.Load()
+	if apiKey := os.Getenv("STEAM_API_KEY"); apiKey != "" {
+		return apiKey
+	}
+	if apiKey, err := loadAPIKeyFromKeychain(); err == nil && apiKey != "" {
+		return apiKey
+	}
+	path, err := getConfigFilePath()
+	if err != nil {
+		return ""
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			loggerFrom(ctx).Warn("could not read config file", "err", err)
+		}
+		return ""
+	}
+	return cfg.SteamAPIKey
+}
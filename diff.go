@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// PlaytimeDiff describes how the playtime of one game changed between two snapshots.
+type PlaytimeDiff struct {
+	AppID  int
+	Name   string
+	Before int
+	After  int
+	Delta  int
+}
+
+// diffPlaytime compares two snapshots of a library by app ID.
+// Games missing from before (e.g. bought since) count as having had no playtime.
+// Arguments:
+//   - before: The older snapshot.
+//   - after: The newer snapshot.
+// Returns the games whose playtime changed, largest increase first.
+func diffPlaytime(before, after []Game) []PlaytimeDiff {
+	previous := make(map[int]int, len(before))
+	for _, game := range before {
+		previous[game.AppID] = game.PlaytimeForever
+	}
+	diffs := make([]PlaytimeDiff, 0)
+	for _, game := range after {
+		old := previous[game.AppID]
+		if game.PlaytimeForever == old {
+			continue
+		}
+		diffs = append(diffs, PlaytimeDiff{
+			AppID:  game.AppID,
+			Name:   game.Name,
+			Before: old,
+			After:  game.PlaytimeForever,
+			Delta:  game.PlaytimeForever - old,
+		})
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Delta > diffs[j].Delta
+	})
+	return diffs
+}
+
+// printPlaytimeDiff fetches the library, prints the games played since the cached
+// snapshot of the profile was taken and replaces the snapshot with the fresh data.
+// Arguments:
+//   - ctx: The context for the request.
+//   - client: The client used to fetch the library.
+//   - profile: The profile whose game cache holds the previous snapshot.
+//   - steamID64: The user's SteamID64.
+//   - unit: The playtime unit, "hours" or "minutes".
+// Returns an error if there is no previous snapshot or the library could not be fetched.
+func printPlaytimeDiff(ctx context.Context, client SteamClient, profile, steamID64, unit string) error {
+	path, err := getCacheFilePath(profile)
+	if err != nil {
+		return err
+	}
+	before, savedAt, err := loadCache(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.New("no previous game list to compare with; run wsipn once first")
+	}
+	if err != nil {
+		return err
+	}
+
+	after, err := listGames(ctx, client, steamID64)
+	if err != nil {
+		return err
+	}
+	if err := saveCache(path, after); err != nil {
+		loggerFrom(ctx).Warn("could not save game cache", "err", err)
+	}
+
+	fmt.Printf("== Played Since %s ==\n", savedAt.Format(time.RFC1123))
+	played := 0
+	for _, diff := range diffPlaytime(before, after) {
+		if diff.Delta <= 0 {
+			continue
+		}
+		fmt.Printf("%s: +%s (%s → %s)\n", diff.Name, formatPlaytime(diff.Delta, unit),
+			formatPlaytime(diff.Before, unit), formatPlaytime(diff.After, unit))
+		played++
+	}
+	if played == 0 {
+		fmt.Println("Nothing played since then.")
+	}
+	return nil
+}
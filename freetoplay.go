@@ -0,0 +1,54 @@
+package main
+
+// knownFreeToPlayAppIDs lists popular free-to-play games. GetOwnedGames is called with
+// include_played_free_games=1 and offers no reliable free-to-play indicator,
+// so this bundled list is the source for Game.FreeToPlay.
+var knownFreeToPlayAppIDs = map[int]bool{
+	440:     true, // Team Fortress 2
+	570:     true, // Dota 2
+	730:     true, // Counter-Strike 2
+	218230:  true, // PlanetSide 2
+	230410:  true, // Warframe
+	236390:  true, // War Thunder
+	238960:  true, // Path of Exile
+	291550:  true, // Brawlhalla
+	304930:  true, // Unturned
+	386360:  true, // SMITE
+	444090:  true, // Paladins
+	578080:  true, // PUBG: BATTLEGROUNDS
+	1085660: true, // Destiny 2
+	1172470: true, // Apex Legends
+	1599340: true, // Lost Ark
+	2357570: true, // Overwatch 2
+}
+
+// isFreeToPlay reports whether the given app ID is in the bundled list of free-to-play games.
+// Arguments:
+//   - appID: The Steam app ID to check.
+// Returns true if the game is known to be free-to-play.
+func isFreeToPlay(appID int) bool {
+	return knownFreeToPlayAppIDs[appID]
+}
+
+// markFreeToPlay sets FreeToPlay on every game found in the bundled free-to-play list.
+// Arguments:
+//   - games: The games to update in place.
+func markFreeToPlay(games []Game) {
+	for i := range games {
+		games[i].FreeToPlay = isFreeToPlay(games[i].AppID)
+	}
+}
+
+// removeFreeToPlay returns the games that are not marked as free-to-play.
+// Arguments:
+//   - games: The games to filter.
+// Returns the paid games in their original order.
+func removeFreeToPlay(games []Game) []Game {
+	paid := make([]Game, 0, len(games))
+	for _, game := range games {
+		if !game.FreeToPlay {
+			paid = append(paid, game)
+		}
+	}
+	return paid
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// FriendRecommendation is a game both users own that the user has not played yet
+// but the friend has.
+type FriendRecommendation struct {
+	Game           Game
+	FriendPlaytime int
+}
+
+// getFriendRecommendations returns the shared games that are unplayed for the user
+// and played by the friend, most played by the friend first.
+// Arguments:
+//   - mine: The user's library.
+//   - friends: The friend's library.
+//   - thresholdHours: The playtime in hours below which a game of the user counts as unplayed.
+// Returns the recommendations.
+func getFriendRecommendations(mine, friends []Game, thresholdHours float64) []FriendRecommendation {
+	friendPlaytime := make(map[int]int, len(friends))
+	for _, game := range friends {
+		friendPlaytime[game.AppID] = game.PlaytimeForever
+	}
+	both, _, _ := compareLibraries(mine, friends)
+	unplayed := unplayedGamesWithThreshold(both, thresholdHours)
+
+	recommendations := make([]FriendRecommendation, 0)
+	for _, game := range unplayed {
+		if playtime := friendPlaytime[game.AppID]; playtime > 0 {
+			recommendations = append(recommendations, FriendRecommendation{Game: game, FriendPlaytime: playtime})
+		}
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].FriendPlaytime > recommendations[j].FriendPlaytime
+	})
+	return recommendations
+}
+
+// printFriendRecommendations resolves a friend's vanity name, fetches their library and prints
+// the shared games the user has not played but the friend has, with the friend's playtime.
+// Arguments:
+//   - ctx: The context for the requests.
+//   - steam: The SteamClient used to fetch the friend's library.
+//   - apiKey: The Steam API key used to resolve the vanity name.
+//   - vanity: The friend's custom profile name.
+//   - games: The user's (filtered) library.
+//   - thresholdHours: The playtime in hours below which a game of the user counts as unplayed.
+//   - unit: The playtime unit, "hours" or "minutes".
+// Returns an error if the name cannot be resolved or the library cannot be fetched.
+func printFriendRecommendations(ctx context.Context, steam SteamClient, apiKey, vanity string, games []Game, thresholdHours float64, unit string) error {
+	resolveCtx, cancel := context.WithTimeout(ctx, apiTimeout)
+	friendID, err := resolveVanityURL(resolveCtx, apiKey, vanity)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("could not resolve %s: %w", vanity, err)
+	}
+	friends, err := listGames(ctx, steam, friendID)
+	if err != nil {
+		return fmt.Errorf("could not fetch library of %s (is the profile public?): %w", vanity, err)
+	}
+
+	recommendations := getFriendRecommendations(games, friends, thresholdHours)
+	fmt.Printf("== Unplayed Games %s Has Played (%d) ==\n", vanity, len(recommendations))
+	if len(recommendations) == 0 {
+		fmt.Println("No shared games to recommend.")
+	}
+	for _, rec := range recommendations {
+		fmt.Printf("%s (%s: %s)\n", rec.Game.Name, vanity, formatPlaytime(rec.FriendPlaytime, unit))
+	}
+	return nil
+}
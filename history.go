@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// getHistoryFilePath returns the file path where previously selected games are recorded.
+// It uses the user's home directory (or --config-path) and a fixed filename ".wsipn_history".
+// Arguments:
+//   - None
+// Returns the file path as a string and an error if the home directory cannot be determined.
+func getHistoryFilePath() (string, error) {
+	home, err := storage.homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".wsipn_history"), nil
+}
+
+// loadHistory returns the names of the last n selected games, oldest first.
+// A missing history file is not an error and yields an empty history.
+// Arguments:
+//   - path: The history file to read.
+//   - n: The number of most recent entries to return.
+// Returns the game names and an error if the file cannot be read.
+func loadHistory(path string, n int) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	if n < 0 {
+		n = 0
+	}
+	if len(names) > n {
+		names = names[len(names)-n:]
+	}
+	return names, nil
+}
+
+// appendHistory records a selected game at the end of the history file, keeping only the
+// last keep entries so that the file does not grow without bound.
+// The file is replaced atomically and has permissions 0600 (read/write for the owner only).
+// Arguments:
+//   - path: The history file to update.
+//   - name: The name of the selected game.
+//   - keep: The number of entries to keep, including the new one.
+// Returns an error if the file cannot be read or written.
+func appendHistory(path string, name string, keep int) error {
+	names, err := loadHistory(path, keep-1)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, previous := range append(names, name) {
+		fmt.Fprintln(&b, previous)
+	}
+	return writeFileAtomic(path, []byte(b.String()))
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it over path,
+// so readers never see a partly written file. The file has permissions 0600.
+// Arguments:
+//   - path: The file to replace.
+//   - data: The new contents.
+// Returns an error if the file cannot be written.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// asciiRamp lists characters from darkest to brightest for the ASCII fallback.
+const asciiRamp = " .:-=+*#%@"
+
+// headerImageURL returns the URL of the Steam store header image of a game.
+// Arguments:
+//   - appID: The app ID of the game.
+// Returns the image URL.
+func headerImageURL(appID int) string {
+	return fmt.Sprintf("https://cdn.akamai.steamstatic.com/steam/apps/%d/header.jpg", appID)
+}
+
+// fetchHeaderImage downloads the Steam store header image of a game.
+// Arguments:
+//   - ctx: The context for the request.
+//   - client: The HTTP client used for the request.
+//   - appID: The app ID of the game.
+// Returns the JPEG data and an error if the download fails.
+func fetchHeaderImage(ctx context.Context, client *http.Client, appID int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", headerImageURL(appID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching header image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Steam CDN returned %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// supportsInlineImages reports whether the terminal understands the iTerm2 inline image protocol,
+// based on the TERM_PROGRAM environment variable.
+// Arguments:
+//   - None
+// Returns true for iTerm2 and WezTerm.
+func supportsInlineImages() bool {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm":
+		return true
+	default:
+		return false
+	}
+}
+
+// writeInlineImage writes an image using the iTerm2 inline image escape sequence, like imgcat does.
+// Arguments:
+//   - w: The terminal to write to.
+//   - data: The encoded image.
+// Returns an error if writing fails.
+func writeInlineImage(w io.Writer, data []byte) error {
+	_, err := fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\a\n",
+		len(data), base64.StdEncoding.EncodeToString(data))
+	return err
+}
+
+// renderASCIIImage converts an image to ASCII art of the given width.
+// Terminal cells are about twice as tall as wide, so every row samples two pixel rows' worth of height.
+// Arguments:
+//   - data: The encoded image (JPEG).
+//   - width: The width of the art in columns.
+// Returns the ASCII art and an error if the image cannot be decoded.
+func renderASCIIImage(data []byte, width int) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decoding image: %w", err)
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 || width < 1 {
+		return "", nil
+	}
+	width = min(width, bounds.Dx())
+	height := max(1, bounds.Dy()*width/bounds.Dx()/2)
+
+	var art strings.Builder
+	for row := 0; row < height; row++ {
+		y := bounds.Min.Y + row*bounds.Dy()/height
+		for col := 0; col < width; col++ {
+			x := bounds.Min.X + col*bounds.Dx()/width
+			r, g, b, _ := img.At(x, y).RGBA()
+			// Rec. 601 luma on 16-bit channels.
+			luma := (299*r + 587*g + 114*b) / 1000
+			art.WriteByte(asciiRamp[int(luma)*(len(asciiRamp)-1)/0xffff])
+		}
+		art.WriteByte('\n')
+	}
+	return art.String(), nil
+}
+
+// showGameImage prints the header image of a game: inline on terminals that support it,
+// as ASCII art everywhere else.
+// Arguments:
+//   - ctx: The context for the download.
+//   - w: The terminal to write to.
+//   - game: The game whose header image to show.
+// Returns an error if the image cannot be downloaded, decoded or written.
+func showGameImage(ctx context.Context, w io.Writer, game Game) error {
+	if game.AppID == 0 {
+		return fmt.Errorf("cannot show image for %s: unknown app ID", game.Name)
+	}
+	data, err := fetchHeaderImage(ctx, httpClient, game.AppID)
+	if err != nil {
+		return err
+	}
+	if supportsInlineImages() {
+		return writeInlineImage(w, data)
+	}
+	art, err := renderASCIIImage(data, terminalWidth())
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, art)
+	return err
+}
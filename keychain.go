@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/99designs/keyring"
+)
+
+const (
+	// keychainService is the service name the API key is stored under in the system keychain.
+	keychainService = "wsipn"
+	// keychainAccount is the account (item key) the API key is stored under.
+	keychainAccount = "steam_api_key"
+)
+
+// openKeychain opens the operating system keychain.
+// Only native keychains are allowed, so a missing one never falls back to a password-protected file.
+// Arguments:
+//   - None
+// Returns the keychain and an error if no native keychain is available.
+func openKeychain() (keyring.Keyring, error) {
+	return keyring.Open(keyring.Config{
+		ServiceName: keychainService,
+		AllowedBackends: []keyring.BackendType{
+			keyring.KeychainBackend,
+			keyring.WinCredBackend,
+			keyring.SecretServiceBackend,
+			keyring.KWalletBackend,
+		},
+	})
+}
+
+// loadAPIKeyFromKeychain reads the Steam API key from the system keychain.
+// Arguments:
+//   - None
+// Returns the API key and an error if the keychain is unavailable or holds no key.
+func loadAPIKeyFromKeychain() (string, error) {
+	ring, err := openKeychain()
+	if err != nil {
+		return "", err
+	}
+	item, err := ring.Get(keychainAccount)
+	if err != nil {
+		return "", err
+	}
+	return string(item.Data), nil
+}
+
+// saveAPIKeyToKeychain stores the Steam API key in the system keychain.
+// Arguments:
+//   - apiKey: The API key to store.
+// Returns an error if the key is empty or the keychain cannot be written.
+func saveAPIKeyToKeychain(apiKey string) error {
+	if apiKey == "" {
+		return errors.New("no API key to save")
+	}
+	ring, err := openKeychain()
+	if err != nil {
+		return fmt.Errorf("opening keychain: %w", err)
+	}
+	return ring.Set(keyring.Item{
+		Key:   keychainAccount,
+		Data:  []byte(apiKey),
+		Label: "WSIPN Steam API key",
+	})
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// LibraryFolder is a Steam library folder on this machine and the games installed in it.
+type LibraryFolder struct {
+	Path string
+	// Apps holds the app IDs listed as installed; older Steam versions do not list them.
+	Apps map[int]bool
+}
+
+// steamLibraryFoldersCandidates returns the usual locations of libraryfolders.vdf on this OS.
+// Arguments:
+//   - None
+// Returns the candidate paths, most likely first.
+func steamLibraryFoldersCandidates() []string {
+	home, _ := os.UserHomeDir()
+	var roots []string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles(x86)", "ProgramFiles"} {
+			if dir := os.Getenv(env); dir != "" {
+				roots = append(roots, filepath.Join(dir, "Steam"))
+			}
+		}
+		roots = append(roots, `C:\Program Files (x86)\Steam`)
+	case "darwin":
+		roots = append(roots, filepath.Join(home, "Library", "Application Support", "Steam"))
+	default:
+		roots = append(roots,
+			filepath.Join(home, ".steam", "steam"),
+			filepath.Join(home, ".local", "share", "Steam"),
+			filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+		)
+	}
+	candidates := make([]string, len(roots))
+	for i, root := range roots {
+		candidates[i] = filepath.Join(root, "steamapps", "libraryfolders.vdf")
+	}
+	return candidates
+}
+
+// findLibraryFoldersFile returns the first existing libraryfolders.vdf in the usual Steam locations.
+// Arguments:
+//   - None
+// Returns the path and an error if Steam does not seem to be installed.
+func findLibraryFoldersFile() (string, error) {
+	for _, path := range steamLibraryFoldersCandidates() {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("could not find Steam's libraryfolders.vdf; is Steam installed?")
+}
+
+// parseLibraryFolders reads the Steam library folders from a libraryfolders.vdf file.
+// Arguments:
+//   - path: The libraryfolders.vdf file.
+// Returns the library folders and an error if the file cannot be read or parsed.
+func parseLibraryFolders(path string) ([]LibraryFolder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseVDF(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Base(path), err)
+	}
+	top, ok := root["libraryfolders"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s: no libraryfolders section", filepath.Base(path))
+	}
+
+	folders := make([]LibraryFolder, 0)
+	for key, value := range top {
+		if _, err := strconv.Atoi(key); err != nil {
+			continue
+		}
+		folder := LibraryFolder{Apps: make(map[int]bool)}
+		switch v := value.(type) {
+		case string:
+			// Old format: "1" "D:\\Games\\Steam"
+			folder.Path = v
+		case map[string]any:
+			folder.Path, _ = v["path"].(string)
+			apps, _ := v["apps"].(map[string]any)
+			for id := range apps {
+				if appID, err := strconv.Atoi(id); err == nil {
+					folder.Apps[appID] = true
+				}
+			}
+		}
+		if folder.Path != "" {
+			folders = append(folders, folder)
+		}
+	}
+	return folders, nil
+}
+
+// isGameInstalled reports whether a game is installed in any of the library folders.
+// Folders without an app list are checked for the game's appmanifest file instead.
+// Arguments:
+//   - folders: The Steam library folders.
+//   - appID: The app ID of the game.
+// Returns true if the game is installed.
+func isGameInstalled(folders []LibraryFolder, appID int) bool {
+	for _, folder := range folders {
+		if folder.Apps[appID] {
+			return true
+		}
+		if len(folder.Apps) == 0 {
+			manifest := filepath.Join(folder.Path, "steamapps", fmt.Sprintf("appmanifest_%d.acf", appID))
+			if _, err := os.Stat(manifest); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterInstalled returns the games installed in any of the library folders.
+// Arguments:
+//   - games: The games to filter.
+//   - folders: The Steam library folders.
+// Returns the installed games in their original order.
+func filterInstalled(games []Game, folders []LibraryFolder) []Game {
+	filtered := make([]Game, 0)
+	for _, game := range games {
+		if isGameInstalled(folders, game.AppID) {
+			filtered = append(filtered, game)
+		}
+	}
+	return filtered
+}
+
+// parseVDF parses Valve's KeyValues text format into nested maps: every value is either
+// a string or a map[string]any. Later duplicate keys overwrite earlier ones.
+// Arguments:
+//   - text: The VDF document.
+// Returns the top-level map and an error if the document is malformed.
+func parseVDF(text string) (map[string]any, error) {
+	tokens, err := tokenizeVDF(text)
+	if err != nil {
+		return nil, err
+	}
+	pos := 0
+	var parseBlock func(nested bool) (map[string]any, error)
+	parseBlock = func(nested bool) (map[string]any, error) {
+		block := make(map[string]any)
+		for pos < len(tokens) {
+			key := tokens[pos]
+			pos++
+			if key.isBrace("}") {
+				if !nested {
+					return nil, errors.New("unexpected }")
+				}
+				return block, nil
+			}
+			if pos >= len(tokens) {
+				return nil, fmt.Errorf("missing value for key %q", key.text)
+			}
+			value := tokens[pos]
+			pos++
+			if value.isBrace("{") {
+				child, err := parseBlock(true)
+				if err != nil {
+					return nil, err
+				}
+				block[key.text] = child
+			} else {
+				block[key.text] = value.text
+			}
+		}
+		if nested {
+			return nil, errors.New("missing }")
+		}
+		return block, nil
+	}
+	return parseBlock(false)
+}
+
+// vdfToken is a brace or string of a VDF document.
+type vdfToken struct {
+	text   string
+	quoted bool
+}
+
+func (t vdfToken) isBrace(brace string) bool { return !t.quoted && t.text == brace }
+
+// tokenizeVDF splits a VDF document into braces and (quoted or bare) strings, skipping // comments.
+// Arguments:
+//   - text: The VDF document.
+// Returns the tokens and an error if a quoted string is not terminated.
+func tokenizeVDF(text string) ([]vdfToken, error) {
+	var tokens []vdfToken
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case c == '{' || c == '}':
+			tokens = append(tokens, vdfToken{text: string(c)})
+			i++
+		case c == '"':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(text) {
+					return nil, errors.New("unterminated string")
+				}
+				if text[i] == '"' {
+					i++
+					break
+				}
+				if text[i] == '\\' && i+1 < len(text) {
+					switch text[i+1] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(text[i+1])
+					}
+					i += 2
+					continue
+				}
+				b.WriteByte(text[i])
+				i++
+			}
+			tokens = append(tokens, vdfToken{text: b.String(), quoted: true})
+		default:
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t\r\n{}\"", rune(text[i])) {
+				i++
+			}
+			tokens = append(tokens, vdfToken{text: text[start:i]})
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// parseLogLevel converts a --log-level value to a slog level.
+// Arguments:
+//   - level: One of "debug", "info", "warn" or "error".
+// Returns the level and an error if the value is unknown.
+func parseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// newLogger creates a logger that writes text records of at least the given level to stderr.
+// Arguments:
+//   - level: The minimum level to log.
+// Returns the logger.
+func newLogger(level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// loggerKey is the context key under which withLogger stores the logger.
+type loggerKey struct{}
+
+// withLogger returns a copy of ctx that carries the logger, for loggerFrom.
+// Arguments:
+//   - ctx: The parent context.
+//   - logger: The logger to carry.
+// Returns the new context.
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// loggerFrom returns the logger that withLogger stored in ctx.
+// Arguments:
+//   - ctx: The context to look in.
+// Returns the logger, or slog.Default() if ctx carries none, e.g. in tests.
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// quiet suppresses informational output; it is set from the --quiet flag in configureRuntime.
+var quiet bool
+
+// infof writes an informational message, such as a status line or hint, to w unless --quiet is set.
+// Results and errors must not go through infof.
+// Arguments:
+//   - w: The writer to write to, usually os.Stdout or os.Stderr.
+//   - format: The fmt format string.
+//   - args: The format arguments.
+func infof(w io.Writer, format string, args ...any) {
+	if quiet {
+		return
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+// fatal logs msg with the given attributes at error level and exits with status 1.
+// Arguments:
+//   - msg: The message to log.
+//   - args: Alternating attribute keys and values.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// loggingTransport is an http.RoundTripper that logs every request URL
+// and response status at debug level before delegating to next.
+// When verbose is set it also traces them in plain text, for --verbose.
+type loggingTransport struct {
+	next    http.RoundTripper
+	logger  *slog.Logger
+	verbose io.Writer
+}
+
+// RoundTrip logs the request, performs it with the wrapped transport and logs the outcome.
+// Arguments:
+//   - req: The request to perform.
+// Returns the response and an error from the wrapped transport.
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := redactURL(req.URL)
+	t.logger.Debug("http request", "method", req.Method, "url", target)
+	if t.verbose != nil {
+		fmt.Fprintf(t.verbose, "%s %s\n", req.Method, target)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.logger.Debug("http request failed", "url", target, "err", err)
+		if t.verbose != nil {
+			fmt.Fprintf(t.verbose, "← %v\n", err)
+		}
+		return nil, err
+	}
+	t.logger.Debug("http response", "url", target, "status", resp.StatusCode)
+	if t.verbose != nil {
+		fmt.Fprintf(t.verbose, "← HTTP %d\n", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// secretQueryParams are the query parameters, compared ignoring case, whose values redactURL hides.
+var secretQueryParams = []string{"key", "access_token", "token", "webapi_token", "password"}
+
+// redactURL returns the URL as a string with the Steam API key, other secret query parameters
+// and any user info password hidden, so debug logs can be shared safely.
+// Arguments:
+//   - u: The URL to redact.
+// Returns the redacted URL.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := u.Query()
+	changed := false
+	for name := range query {
+		for _, secret := range secretQueryParams {
+			if strings.EqualFold(name, secret) {
+				query.Set(name, "REDACTED")
+				changed = true
+			}
+		}
+	}
+	if changed {
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.Redacted()
+}
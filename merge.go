@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// mergeLibraries combines several libraries into one, keeping one entry per app ID:
+// the copy with the most playtime.
+// Arguments:
+//   - libraries: The libraries to merge.
+// Returns the merged library sorted alphabetically by name.
+func mergeLibraries(libraries ...[]Game) []Game {
+	byAppID := make(map[int]Game)
+	for _, library := range libraries {
+		for _, game := range library {
+			if existing, ok := byAppID[game.AppID]; !ok || game.PlaytimeForever > existing.PlaytimeForever {
+				byAppID[game.AppID] = game
+			}
+		}
+	}
+	merged := make([]Game, 0, len(byAppID))
+	for _, game := range byAppID {
+		merged = append(merged, game)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].Name != merged[j].Name {
+			return merged[i].Name < merged[j].Name
+		}
+		return merged[i].AppID < merged[j].AppID
+	})
+	return merged
+}
+
+// listAllProfilesGames fetches the library of every saved profile, each through its own
+// game cache, and merges them with mergeLibraries.
+// Profiles whose SteamID64 or library cannot be loaded are logged and skipped.
+// Arguments:
+//   - ctx: The context for the requests.
+//   - client: The SteamClient used to fetch the libraries.
+//   - ttl: The maximum age of a cached game list that may be reused.
+// Returns the merged library and an error if there are no saved profiles or none could be loaded.
+func listAllProfilesGames(ctx context.Context, client SteamClient, ttl time.Duration) ([]Game, error) {
+	profiles, err := listProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("could not list profiles: %w", err)
+	}
+	if len(profiles) == 0 {
+		return nil, errors.New("no saved profiles; log in with --profile <name> first")
+	}
+
+	var libraries [][]Game
+	for _, profile := range profiles {
+		steamID64, err := loadSteamID64(profile)
+		if err != nil {
+			loggerFrom(ctx).Warn("skipping profile", "profile", profile, "err", err)
+			continue
+		}
+		games, err := listGamesCached(ctx, client, profile, steamID64, ttl)
+		if err != nil {
+			loggerFrom(ctx).Warn("skipping profile", "profile", profile, "err", err)
+			continue
+		}
+		infof(os.Stdout, "✔️ Loaded %d games of profile %q\n", len(games), profile)
+		libraries = append(libraries, games)
+	}
+	if len(libraries) == 0 {
+		return nil, errors.New("could not load the library of any saved profile")
+	}
+	return mergeLibraries(libraries...), nil
+}
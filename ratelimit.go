@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// perGameRateLimit is how many per-game requests (store details, achievements) are sent per second.
+// It is set from the --rate-limit flag in configureRuntime. The store API throttles clients that
+// send more than about one request per second.
+var perGameRateLimit = 1.0
+
+// maxRateLimit is the highest accepted --rate-limit. Steam starts answering 429 well below it,
+// so higher values would only trade the rate limiter for retries.
+const maxRateLimit = 10.0
+
+// RateLimiter spaces out calls to at most a fixed number per second using a time.Ticker.
+// The first Wait returns immediately. A RateLimiter is not safe for concurrent use.
+type RateLimiter struct {
+	ticker  *time.Ticker
+	started bool
+}
+
+// NewRateLimiter creates a RateLimiter allowing rps calls per second.
+// Arguments:
+//   - rps: The number of calls per second; parseFlags only accepts values in (0, maxRateLimit].
+//     Other values are clamped so that the ticker cannot panic.
+// Returns the limiter; call Stop when done with it.
+func NewRateLimiter(rps float64) *RateLimiter {
+	var interval time.Duration
+	switch ns := float64(time.Second) / rps; {
+	case !(ns >= 1): // also catches NaN
+		interval = time.Nanosecond
+	case ns >= math.MaxInt64:
+		interval = math.MaxInt64
+	default:
+		interval = time.Duration(ns)
+	}
+	return &RateLimiter{ticker: time.NewTicker(interval)}
+}
+
+// Wait blocks until the next call is allowed.
+// Arguments:
+//   - ctx: The context bounding the wait.
+// Returns nil when the call may proceed, or the context error if ctx is done first.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !l.started {
+		l.started = true
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.ticker.C:
+		return nil
+	}
+}
+
+// Stop releases the limiter's ticker.
+// Arguments:
+//   - None
+func (l *RateLimiter) Stop() {
+	l.ticker.Stop()
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report holds everything the program has computed about a library
+// and is handed to a Renderer for output.
+type Report struct {
+	TotalGames      int
+	Threshold       int
+	Unplayed        []Game
+	UnplayedPercent float64
+	PlaytimeUnit    string
+	InactiveDays    int
+	InactiveCount   int
+	TotalPlaytime   int
+	Stats           PlaytimeStats
+	LeastPlayed     Game
+	MostPlayed      Game
+	Streak          *Game
+	AlmostThere     *Game
+	Neglected       *Game
+	LongestUnplayed *Game
+	Favourite       *Game
+	AtPercentile    *Game
+	Percentile      float64
+	ClosestToGoal   *Game
+	GoalMinutes     int
+	TopPlayed       []Game
+	RandomUnplayed  []Game
+}
+
+// Renderer writes a Report in a specific output format.
+type Renderer interface {
+	Render(w io.Writer, report Report) error
+}
+
+// newRenderer returns the Renderer for the given --format value.
+// Arguments:
+//   - format: One of "text", "json", "csv" or "markdown".
+// Returns the renderer and an error if the format is unknown.
+func newRenderer(format string) (Renderer, error) {
+	switch format {
+	case "text":
+		return TextRenderer{}, nil
+	case "json":
+		return JSONRenderer{}, nil
+	case "csv":
+		return CSVRenderer{}, nil
+	case "markdown":
+		return MarkdownRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// TextRenderer renders a Report as human readable text.
+type TextRenderer struct{}
+
+// Render writes the report as the default human readable summary.
+// Arguments:
+//   - w: The writer to render to.
+//   - report: The report to render.
+// Returns an error if writing fails.
+func (TextRenderer) Render(w io.Writer, report Report) error {
+	ew := &errWriter{w: w}
+	ew.printf("== Welcome to WSIPN 1.0 ==\n")
+	ew.printf("Total games: %d, Unplayed games (%s): %d", report.TotalGames, describeThreshold(report.Threshold), len(report.Unplayed))
+	if report.InactiveDays > 0 {
+		ew.printf(", Inactive for more than %d days: %d", report.InactiveDays, report.InactiveCount)
+	}
+	ew.printf("\n")
+	ew.printf("%.1f%% of your library is unplayed\n", report.UnplayedPercent)
+	ew.printf("Total playtime: %s\n", formatDuration(report.TotalPlaytime))
+	unit := report.PlaytimeUnit
+	ew.printf("Playtime: mean %s, median %s, std dev %s, total %s\n",
+		formatPlaytime(int(math.Round(report.Stats.Mean)), unit), formatPlaytime(int(math.Round(report.Stats.Median)), unit),
+		formatPlaytime(int(math.Round(report.Stats.StdDev)), unit), formatPlaytime(report.Stats.Total, unit))
+	ew.printf("Games with %s:\n", describeThreshold(report.Threshold))
+	for _, game := range report.Unplayed {
+		ew.printf("%s\n", game.Name)
+	}
+
+	ew.printf("\n== Least Played Game ==\n")
+	ew.printf("%s (%s)\n", report.LeastPlayed.Name, formatPlaytime(report.LeastPlayed.PlaytimeForever, unit))
+	ew.printf("\n== Most Played Game ==\n")
+	ew.printf("%s (%s)\n", report.MostPlayed.Name, formatPlaytime(report.MostPlayed.PlaytimeForever, unit))
+
+	if report.Streak != nil {
+		ew.printf("\n== Keep the Streak ==\n")
+		ew.printf("%s (%s in the last two weeks)\n", report.Streak.Name, formatPlaytime(report.Streak.PlaytimeTwoWeeks, unit))
+	}
+
+	if report.AlmostThere != nil {
+		ew.printf("\n== Almost There ==\n")
+		ew.printf("%s (%s to the next full hour)\n", report.AlmostThere.Name, formatPlaytime(60-report.AlmostThere.PlaytimeForever%60, unit))
+	}
+
+	if report.AtPercentile != nil {
+		ew.printf("\n== %gth Percentile by Playtime ==\n", report.Percentile*100)
+		ew.printf("%s (%s)\n", report.AtPercentile.Name, formatPlaytime(report.AtPercentile.PlaytimeForever, unit))
+	}
+
+	if report.ClosestToGoal != nil {
+		ew.printf("\n== Closest to %s ==\n", formatPlaytime(report.GoalMinutes, unit))
+		ew.printf("%s (%s to go)\n", report.ClosestToGoal.Name, formatPlaytime(report.GoalMinutes-report.ClosestToGoal.PlaytimeForever, unit))
+	}
+
+	if report.Neglected != nil {
+		ew.printf("\n== Abandoned Long Ago ==\n")
+		ew.printf("%s (last played %s)\n", report.Neglected.Name, time.Unix(report.Neglected.RtimeLastPlayed, 0).Format("2006-01-02"))
+	}
+
+	if report.LongestUnplayed != nil {
+		ew.printf("\n== Hall of Shame ==\n")
+		ew.printf("%s (owned the longest without ever being played)\n", report.LongestUnplayed.Name)
+	}
+
+	if report.Favourite != nil {
+		ew.printf("\n== All-Time Favourite ==\n")
+		ew.printf("%s (%s in total)\n", report.Favourite.Name, formatPlaytime(report.Favourite.PlaytimeForever, unit))
+	}
+
+	ew.printf("\n== Top %d Most Played ==\n", len(report.TopPlayed))
+	for i, game := range report.TopPlayed {
+		ew.printf("%2d. %s (%s)\n", i+1, game.Name, formatPlaytime(game.PlaytimeForever, unit))
+	}
+
+	switch len(report.RandomUnplayed) {
+	case 0:
+		ew.printf("\nNo unplayed games to pick from.\n")
+	case 1:
+		ew.printf("\n== Random Game Selection ==\n")
+		ew.printf("Randomly selected game to play: %s\n", report.RandomUnplayed[0].Name)
+	default:
+		ew.printf("\n== Random Game Selection ==\n")
+		ew.printf("Randomly selected games to play:\n")
+		for i, game := range report.RandomUnplayed {
+			ew.printf("%2d. %s\n", i+1, game.Name)
+		}
+	}
+	return ew.err
+}
+
+// JSONRenderer renders a Report as a single JSON object.
+type JSONRenderer struct{}
+
+// jsonReport is the JSON representation of a Report.
+type jsonReport struct {
+	RandomUnplayed  []jsonGame    `json:"random_unplayed"`
+	LeastPlayed     jsonGame      `json:"least_played"`
+	MostPlayed      jsonGame      `json:"most_played"`
+	Streak          *jsonGame     `json:"streak"`
+	AlmostThere     *jsonGame     `json:"almost_there"`
+	Neglected       *jsonGame     `json:"neglected"`
+	LongestUnplayed *jsonGame     `json:"longest_unplayed"`
+	Favourite       *jsonGame     `json:"favourite"`
+	AtPercentile    *jsonGame     `json:"at_percentile,omitempty"`
+	ClosestToGoal   *jsonGame     `json:"closest_to_goal,omitempty"`
+	Stats           PlaytimeStats `json:"stats"`
+}
+
+// jsonGame is a Game with its total playtime also formatted in the selected unit.
+type jsonGame struct {
+	Game
+	Playtime string `json:"playtime"`
+}
+
+// newJSONGame wraps a game for JSON output.
+// Arguments:
+//   - game: The game to wrap.
+//   - unit: The playtime unit, "hours" or "minutes".
+// Returns the wrapped game.
+func newJSONGame(game Game, unit string) jsonGame {
+	return jsonGame{Game: game, Playtime: formatPlaytime(game.PlaytimeForever, unit)}
+}
+
+// newJSONGamePtr is newJSONGame for optional games.
+// Arguments:
+//   - game: The game to wrap, or nil.
+//   - unit: The playtime unit, "hours" or "minutes".
+// Returns the wrapped game, or nil if game is nil.
+func newJSONGamePtr(game *Game, unit string) *jsonGame {
+	if game == nil {
+		return nil
+	}
+	g := newJSONGame(*game, unit)
+	return &g
+}
+
+// Render writes the random picks, least and most played games and statistics as one JSON object.
+// Arguments:
+//   - w: The writer to render to.
+//   - report: The report to render.
+// Returns an error if encoding or writing fails.
+func (JSONRenderer) Render(w io.Writer, report Report) error {
+	unit := report.PlaytimeUnit
+	random := make([]jsonGame, 0, len(report.RandomUnplayed))
+	for _, game := range report.RandomUnplayed {
+		random = append(random, newJSONGame(game, unit))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonReport{
+		RandomUnplayed:  random,
+		LeastPlayed:     newJSONGame(report.LeastPlayed, unit),
+		MostPlayed:      newJSONGame(report.MostPlayed, unit),
+		Streak:          newJSONGamePtr(report.Streak, unit),
+		AlmostThere:     newJSONGamePtr(report.AlmostThere, unit),
+		Neglected:       newJSONGamePtr(report.Neglected, unit),
+		LongestUnplayed: newJSONGamePtr(report.LongestUnplayed, unit),
+		Favourite:       newJSONGamePtr(report.Favourite, unit),
+		AtPercentile:    newJSONGamePtr(report.AtPercentile, unit),
+		ClosestToGoal:   newJSONGamePtr(report.ClosestToGoal, unit),
+		Stats:           report.Stats,
+	})
+}
+
+// CSVRenderer renders the unplayed games of a Report as CSV, one game per row.
+type CSVRenderer struct{}
+
+// Render writes a "name,playtime_minutes" header followed by one row per unplayed game.
+// Arguments:
+//   - w: The writer to render to.
+//   - report: The report to render.
+// Returns an error if writing fails.
+func (CSVRenderer) Render(w io.Writer, report Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"name", "playtime_minutes"}); err != nil {
+		return err
+	}
+	for _, game := range report.Unplayed {
+		if err := cw.Write([]string{game.Name, strconv.Itoa(game.PlaytimeForever)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderSelection writes only the selected games in the given format, for --quiet:
+// names one per line for text, a JSON array, CSV rows or a Markdown table.
+// Arguments:
+//   - w: The writer to render to.
+//   - games: The selected games.
+//   - format: One of "text", "json", "csv" or "markdown".
+//   - unit: The playtime unit, "hours" or "minutes".
+// Returns an error if the format is unknown or writing fails.
+func renderSelection(w io.Writer, games []Game, format, unit string) error {
+	switch format {
+	case "text":
+		ew := &errWriter{w: w}
+		for _, game := range games {
+			ew.printf("%s\n", game.Name)
+		}
+		return ew.err
+	case "json":
+		selected := make([]jsonGame, 0, len(games))
+		for _, game := range games {
+			selected = append(selected, newJSONGame(game, unit))
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(selected)
+	case "csv":
+		return CSVRenderer{}.Render(w, Report{Unplayed: games})
+	case "markdown":
+		return exportMarkdown(games, unit, w)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// formatPlaytime formats a playtime for display, e.g. "2.5h" or "150m".
+// Arguments:
+//   - minutes: The playtime in minutes.
+//   - unit: "minutes" for whole minutes; anything else formats hours with one decimal.
+// Returns the formatted playtime.
+func formatPlaytime(minutes int, unit string) string {
+	if unit == "minutes" {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%.1fh", float64(minutes)/60)
+}
+
+// MarkdownRenderer renders the unplayed games of a Report as a Markdown table.
+type MarkdownRenderer struct{}
+
+// Render writes the unplayed games as a Markdown table.
+// Arguments:
+//   - w: The writer to render to.
+//   - report: The report to render.
+// Returns an error if writing fails.
+func (MarkdownRenderer) Render(w io.Writer, report Report) error {
+	return exportMarkdown(report.Unplayed, report.PlaytimeUnit, w)
+}
+
+// exportMarkdown writes the games as a Markdown table with the columns "#", "Game" and "Playtime".
+// Pipes and backslashes in game names are escaped so they do not break the table.
+// Arguments:
+//   - games: The games to export.
+//   - unit: The playtime unit, "hours" or "minutes".
+//   - w: The writer to write the table to.
+// Returns an error if writing fails.
+func exportMarkdown(games []Game, unit string, w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("| # | Game | Playtime |\n")
+	ew.printf("| ---: | --- | ---: |\n")
+	escaper := strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ")
+	for i, game := range games {
+		ew.printf("| %d | %s | %s |\n", i+1, escaper.Replace(game.Name), formatPlaytime(game.PlaytimeForever, unit))
+	}
+	return ew.err
+}
+
+// errWriter wraps an io.Writer and remembers the first write error,
+// so a sequence of prints only needs to be checked once at the end.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+// printf formats and writes to the underlying writer unless an earlier write failed.
+// Arguments:
+//   - format: The format string.
+//   - args: The values to format.
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
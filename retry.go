@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// retryBaseDelay is the delay before the first retry; it doubles after every attempt.
+// It is a variable so that tests can shorten it.
+var retryBaseDelay = 500 * time.Millisecond
+
+// maxAPIAttempts is how many times a Steam API request is attempted in total.
+// It is set from the --max-retries flag in configureRuntime.
+var maxAPIAttempts = 4
+
+// apiTimeout bounds a single Steam Web API call, including its retries.
+// It is set from the --timeout flag in configureRuntime.
+var apiTimeout = 10 * time.Second
+
+// permanentError marks an error that withRetry must not retry.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent wraps err so that withRetry returns it immediately instead of retrying.
+// Arguments:
+//   - err: The error to wrap.
+// Returns the wrapped error.
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// withRetry calls fn until it succeeds, up to maxAttempts times,
+// waiting with exponential back-off (base 500ms, multiplier 2, jitter ±10%) between attempts.
+// Errors wrapped with permanent are returned immediately.
+// Arguments:
+//   - ctx: The context bounding the whole retry loop.
+//   - maxAttempts: The maximum number of calls to fn; values below 1 are treated as 1.
+//   - fn: The operation to attempt.
+// Returns nil on success, otherwise the last error from fn or the context error.
+func withRetry(ctx context.Context, maxAttempts int, fn func() error) error {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if attempt >= maxAttempts {
+			return err
+		}
+
+		jitter := 0.9 + rand.Float64()*0.2
+		timer := time.NewTimer(time.Duration(float64(delay) * jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
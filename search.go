@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSearchDistance is the largest edit distance at which a name still counts as a fuzzy match.
+const maxSearchDistance = 2
+
+// searchGames finds the games matching a query, ignoring case: exact matches first,
+// then names containing the query, then names within maxSearchDistance edits of it.
+// Games of equal relevance keep their original relative order.
+// Arguments:
+//   - games: The games to search.
+//   - query: The text to look for.
+// Returns the matching games ordered by relevance.
+func searchGames(games []Game, query string) []Game {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return []Game{}
+	}
+
+	type match struct {
+		game Game
+		rank int
+	}
+	matches := make([]match, 0)
+	for _, game := range games {
+		name := strings.ToLower(game.Name)
+		switch {
+		case name == query:
+			matches = append(matches, match{game, 0})
+		case strings.Contains(name, query):
+			matches = append(matches, match{game, 1})
+		case levenshtein(name, query) <= maxSearchDistance:
+			matches = append(matches, match{game, 2})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].rank < matches[j].rank
+	})
+
+	found := make([]Game, len(matches))
+	for i, m := range matches {
+		found[i] = m.game
+	}
+	return found
+}
+
+// levenshtein returns the edit distance between two strings: the number of single-character
+// insertions, deletions and substitutions needed to turn a into b.
+// Arguments:
+//   - a: The first string.
+//   - b: The second string.
+// Returns the edit distance, counted in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Only the previous row of the distance matrix is needed.
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
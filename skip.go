@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// getSkipFilePath returns the file path of the list of games never to suggest.
+// It uses the user's home directory (or --config-path) and a fixed filename ".wsipn_skip.json".
+// Arguments:
+//   - None
+// Returns the file path as a string and an error if the home directory cannot be determined.
+func getSkipFilePath() (string, error) {
+	home, err := storage.homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".wsipn_skip.json"), nil
+}
+
+// loadSkipList reads the app IDs stored in the skip file.
+// A missing skip file is not an error and yields an empty list.
+// Arguments:
+//   - path: The skip file to read.
+// Returns the app IDs and an error if the file cannot be read or parsed.
+func loadSkipList(path string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var appIDs []int
+	if err := json.Unmarshal(data, &appIDs); err != nil {
+		return nil, fmt.Errorf("invalid skip file: %w", err)
+	}
+	return appIDs, nil
+}
+
+// saveSkipList writes the app IDs to the skip file as a JSON array.
+// Arguments:
+//   - path: The skip file to write.
+//   - appIDs: The app IDs to store.
+// Returns an error if the list cannot be encoded or the file cannot be written.
+func saveSkipList(path string, appIDs []int) error {
+	if appIDs == nil {
+		appIDs = []int{}
+	}
+	data, err := json.MarshalIndent(appIDs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding skip list: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// addToSkipList adds a game to ~/.wsipn_skip.json so it is never suggested again.
+// Adding a game that is already skipped does nothing.
+// Arguments:
+//   - appID: The app ID of the game to skip.
+// Returns an error if the skip file cannot be read or written.
+func addToSkipList(appID int) error {
+	path, err := getSkipFilePath()
+	if err != nil {
+		return err
+	}
+	appIDs, err := loadSkipList(path)
+	if err != nil {
+		return err
+	}
+	for _, id := range appIDs {
+		if id == appID {
+			return nil
+		}
+	}
+	return saveSkipList(path, append(appIDs, appID))
+}
+
+// removeFromSkipList removes a game from ~/.wsipn_skip.json so it can be suggested again.
+// Removing a game that is not skipped does nothing.
+// Arguments:
+//   - appID: The app ID of the game to stop skipping.
+// Returns an error if the skip file cannot be read or written.
+func removeFromSkipList(appID int) error {
+	path, err := getSkipFilePath()
+	if err != nil {
+		return err
+	}
+	appIDs, err := loadSkipList(path)
+	if err != nil {
+		return err
+	}
+	kept := make([]int, 0, len(appIDs))
+	for _, id := range appIDs {
+		if id != appID {
+			kept = append(kept, id)
+		}
+	}
+	if len(kept) == len(appIDs) {
+		return nil
+	}
+	return saveSkipList(path, kept)
+}
+
+// removeSkipped returns the games whose app ID is not in the skip list.
+// Arguments:
+//   - games: The games to filter.
+//   - appIDs: The skipped app IDs.
+// Returns the remaining games in their original order.
+func removeSkipped(games []Game, appIDs []int) []Game {
+	if len(appIDs) == 0 {
+		return games
+	}
+	skipped := make(map[int]bool, len(appIDs))
+	for _, id := range appIDs {
+		skipped[id] = true
+	}
+	kept := make([]Game, 0, len(games))
+	for _, game := range games {
+		if !skipped[game.AppID] {
+			kept = append(kept, game)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PlaytimeStats holds aggregate statistics about the playtime of a library, in minutes.
+type PlaytimeStats struct {
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	StdDev float64 `json:"std_dev"`
+	Total  int     `json:"total"`
+}
+
+// getUnplayedPercent returns the share of the library that counts as unplayed.
+// Arguments:
+//   - games: The games in the library.
+//   - thresholdMinutes: The playtime in minutes below which a game counts as unplayed.
+// Returns the percentage between 0 and 100, or 0 for an empty library.
+func getUnplayedPercent(games []Game, thresholdMinutes int) float64 {
+	if len(games) == 0 {
+		return 0
+	}
+	return float64(len(unplayedGames(games, thresholdMinutes))) * 100 / float64(len(games))
+}
+
+// getTotalPlaytime returns the combined playtime of all games.
+// Arguments:
+//   - games: The games to sum up.
+// Returns the total playtime in minutes, 0 for no games.
+func getTotalPlaytime(games []Game) int {
+	total := 0
+	for _, game := range games {
+		total += game.PlaytimeForever
+	}
+	return total
+}
+
+// formatDuration formats minutes as hours and minutes with the hours grouped in thousands,
+// e.g. "1 234h 30m".
+// Arguments:
+//   - minutes: The duration in minutes.
+// Returns the formatted duration.
+func formatDuration(minutes int) string {
+	hours := strconv.Itoa(minutes / 60)
+	var grouped strings.Builder
+	for i, digit := range hours {
+		if i > 0 && (len(hours)-i)%3 == 0 {
+			grouped.WriteByte(' ')
+		}
+		grouped.WriteRune(digit)
+	}
+	return fmt.Sprintf("%sh %dm", grouped.String(), minutes%60)
+}
+
+// getPlaytimeStats computes the mean, median, population standard deviation
+// and total of the games' playtime.
+// Mean and variance are computed in a single pass using Welford's algorithm,
+// the median by sorting a copy of the playtimes.
+// Arguments:
+//   - games: The games to summarize.
+// Returns the statistics and an error if the slice is empty.
+func getPlaytimeStats(games []Game) (PlaytimeStats, error) {
+	if len(games) == 0 {
+		return PlaytimeStats{}, errors.New("no games to compute statistics for")
+	}
+
+	var stats PlaytimeStats
+	var m2 float64
+	playtimes := make([]int, len(games))
+	for i, game := range games {
+		playtimes[i] = game.PlaytimeForever
+		stats.Total += game.PlaytimeForever
+
+		x := float64(game.PlaytimeForever)
+		delta := x - stats.Mean
+		stats.Mean += delta / float64(i+1)
+		m2 += delta * (x - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(m2 / float64(len(games)))
+
+	sort.Ints(playtimes)
+	mid := len(playtimes) / 2
+	if len(playtimes)%2 == 0 {
+		stats.Median = float64(playtimes[mid-1]+playtimes[mid]) / 2
+	} else {
+		stats.Median = float64(playtimes[mid])
+	}
+	return stats, nil
+}
+
+// bucketMultipliers are the bucket boundaries as multiples of the bucket size.
+// With a bucket size of 60 minutes they give 0-60min, 1h-2h, 2h-5h, 5h-10h and 10h+.
+var bucketMultipliers = []int{1, 2, 5, 10}
+
+// playtimeBucketLabels returns the bucket labels for the given bucket size, in ascending order.
+// Arguments:
+//   - bucketSizeMinutes: The upper bound of the first bucket in minutes.
+// Returns the labels.
+func playtimeBucketLabels(bucketSizeMinutes int) []string {
+	labels := make([]string, 0, len(bucketMultipliers)+1)
+	labels = append(labels, fmt.Sprintf("0-%dmin", bucketSizeMinutes))
+	for i := 1; i < len(bucketMultipliers); i++ {
+		labels = append(labels, formatBucketBound(bucketMultipliers[i-1]*bucketSizeMinutes)+"-"+
+			formatBucketBound(bucketMultipliers[i]*bucketSizeMinutes))
+	}
+	last := bucketMultipliers[len(bucketMultipliers)-1] * bucketSizeMinutes
+	return append(labels, formatBucketBound(last)+"+")
+}
+
+// formatBucketBound formats a bucket boundary as whole hours when possible, otherwise as minutes.
+// Arguments:
+//   - minutes: The boundary in minutes.
+// Returns the formatted boundary, e.g. "2h" or "90min".
+func formatBucketBound(minutes int) string {
+	if minutes%60 == 0 {
+		return strconv.Itoa(minutes/60) + "h"
+	}
+	return strconv.Itoa(minutes) + "min"
+}
+
+// groupByPlaytimeBucket groups the games into playtime ranges whose boundaries are
+// 1, 2, 5 and 10 times bucketSizeMinutes. Each bucket includes its lower bound.
+// Arguments:
+//   - games: The games to group.
+//   - bucketSizeMinutes: The upper bound of the first bucket in minutes; must be positive.
+// Returns the games keyed by bucket label (see playtimeBucketLabels); empty buckets are omitted.
+func groupByPlaytimeBucket(games []Game, bucketSizeMinutes int) map[string][]Game {
+	labels := playtimeBucketLabels(bucketSizeMinutes)
+	buckets := make(map[string][]Game)
+	for _, game := range games {
+		i := 0
+		for i < len(bucketMultipliers) && game.PlaytimeForever >= bucketMultipliers[i]*bucketSizeMinutes {
+			i++
+		}
+		buckets[labels[i]] = append(buckets[labels[i]], game)
+	}
+	return buckets
+}
+
+// terminalWidth returns the terminal width from the COLUMNS environment variable,
+// falling back to 80 columns.
+// Arguments:
+//   - None
+// Returns the width in columns.
+func terminalWidth() int {
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
+
+// renderHistogram formats the bucket counts as an ASCII bar chart
+// whose longest bar fits within the given width.
+// Arguments:
+//   - buckets: The games grouped by bucket label.
+//   - labels: The bucket labels in display order.
+//   - width: The available width in columns.
+// Returns the chart, one line per bucket.
+func renderHistogram(buckets map[string][]Game, labels []string, width int) string {
+	labelWidth, maxCount := 0, 0
+	for _, label := range labels {
+		labelWidth = max(labelWidth, len(label))
+		maxCount = max(maxCount, len(buckets[label]))
+	}
+	countWidth := len(strconv.Itoa(maxCount))
+	barWidth := max(width-labelWidth-countWidth-4, 1)
+
+	var b strings.Builder
+	for _, label := range labels {
+		count := len(buckets[label])
+		bar := 0
+		if maxCount > 0 {
+			bar = count * barWidth / maxCount
+		}
+		fmt.Fprintf(&b, "%-*s %*d %s\n", labelWidth, label, countWidth, count, strings.Repeat("█", bar))
+	}
+	return b.String()
+}
+
+// defaultStatBuckets are the minute boundaries used by --bucket-stats.
+var defaultStatBuckets = []int{0, 60, 300, 600, 1200}
+
+// bucketRangeLabel returns the label of the i-th range formed by sorted minute boundaries,
+// e.g. "60-300", or "1200+" for the open-ended last range.
+// Arguments:
+//   - buckets: The sorted minute boundaries.
+//   - i: The index of the range's lower boundary.
+// Returns the label.
+func bucketRangeLabel(buckets []int, i int) string {
+	if i == len(buckets)-1 {
+		return fmt.Sprintf("%d+", buckets[i])
+	}
+	return fmt.Sprintf("%d-%d", buckets[i], buckets[i+1])
+}
+
+// getAveragePlaytimeByBucket groups games into the half-open playtime ranges formed by the
+// boundaries and returns the mean playtime per range. The last range is open-ended;
+// games below the first boundary and ranges without games are left out.
+// Arguments:
+//   - games: The games to group.
+//   - buckets: The sorted minute boundaries, e.g. [0, 60, 300, 600].
+// Returns the mean playtime in minutes keyed by range label, e.g. "0-60" or "600+".
+func getAveragePlaytimeByBucket(games []Game, buckets []int) map[string]float64 {
+	averages := make(map[string]float64)
+	if len(games) == 0 || len(buckets) == 0 {
+		return averages
+	}
+	sums := make([]int, len(buckets))
+	counts := make([]int, len(buckets))
+	for _, game := range games {
+		// The last boundary not above the playtime is the game's range.
+		i := sort.Search(len(buckets), func(i int) bool { return buckets[i] > game.PlaytimeForever }) - 1
+		if i < 0 {
+			continue
+		}
+		sums[i] += game.PlaytimeForever
+		counts[i]++
+	}
+	for i := range buckets {
+		if counts[i] > 0 {
+			averages[bucketRangeLabel(buckets, i)] = float64(sums[i]) / float64(counts[i])
+		}
+	}
+	return averages
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultSteamAPIBaseURL is the base URL of the public Steam Web API.
+const defaultSteamAPIBaseURL = "https://api.steampowered.com"
+
+// defaultSteamStoreURL is the base URL of the Steam store, which serves app details and wishlists.
+const defaultSteamStoreURL = "https://store.steampowered.com"
+
+// SteamAPIConfig describes where Steam Web API requests are sent.
+type SteamAPIConfig struct {
+	// BaseURL is the API root that endpoint paths are resolved against,
+	// e.g. a corporate proxy or a local mock server.
+	BaseURL *url.URL
+	// StoreURL is the store root used by storeEndpoint.
+	StoreURL *url.URL
+	// OpenIDURL is the OpenID 2.0 provider endpoint used to log in.
+	OpenIDURL string
+}
+
+// steamAPI is the Steam Web API configuration used by all requests.
+// It is set from the --api-base-url flag in configureRuntime.
+var steamAPI = mustSteamAPIConfig(defaultSteamAPIBaseURL)
+
+// newSteamAPIConfig parses and validates a Steam Web API base URL.
+// Arguments:
+//   - baseURL: An absolute http or https URL, optionally with a path prefix.
+// Returns the configuration and an error if the URL is invalid.
+func newSteamAPIConfig(baseURL string) (SteamAPIConfig, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return SteamAPIConfig{}, fmt.Errorf("invalid API base URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return SteamAPIConfig{}, fmt.Errorf("invalid API base URL %q: must be an absolute http or https URL", baseURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return SteamAPIConfig{}, fmt.Errorf("invalid API base URL %q: must not have a query or fragment", baseURL)
+	}
+	store, _ := url.Parse(defaultSteamStoreURL)
+	return SteamAPIConfig{BaseURL: u, StoreURL: store, OpenIDURL: steamOpenIDURL}, nil
+}
+
+// mustSteamAPIConfig is newSteamAPIConfig for URLs known to be valid.
+// Arguments:
+//   - baseURL: An absolute http or https URL.
+// Returns the configuration; it panics if the URL is invalid.
+func mustSteamAPIConfig(baseURL string) SteamAPIConfig {
+	config, err := newSteamAPIConfig(baseURL)
+	if err != nil {
+		panic(err)
+	}
+	return config
+}
+
+// endpoint builds the URL of an API method below the base URL.
+// Arguments:
+//   - path: The method path, e.g. "IPlayerService/GetOwnedGames/v1/".
+//   - query: The query parameters.
+// Returns the full URL.
+func (c SteamAPIConfig) endpoint(path string, query url.Values) string {
+	return joinEndpoint(c.BaseURL, path, query)
+}
+
+// storeEndpoint builds the URL of a Steam store page or API below the store URL.
+// Arguments:
+//   - path: The path, e.g. "api/appdetails".
+//   - query: The query parameters.
+// Returns the full URL.
+func (c SteamAPIConfig) storeEndpoint(path string, query url.Values) string {
+	return joinEndpoint(c.StoreURL, path, query)
+}
+
+// joinEndpoint resolves path below base and sets the query, keeping a trailing slash on path.
+// Arguments:
+//   - base: The root URL.
+//   - path: The path below base.
+//   - query: The query parameters.
+// Returns the full URL.
+func joinEndpoint(base *url.URL, path string, query url.Values) string {
+	u := base.JoinPath(path)
+	// JoinPath drops the trailing slash the Steam method paths end with.
+	if strings.HasSuffix(path, "/") && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// SteamClient fetches library data from Steam.
+// It lets the selection logic run against a fake implementation in tests.
+type SteamClient interface {
+	GetOwnedGames(ctx context.Context, steamID64 string) ([]Game, error)
+}
+
+// HTTPSteamClient implements SteamClient using the Steam Web API.
+type HTTPSteamClient struct {
+	Client *http.Client
+	APIKey string
+	API    SteamAPIConfig
+}
+
+// NewHTTPSteamClient creates a SteamClient that talks to the Steam Web API.
+// Arguments:
+//   - client: The HTTP client used for requests.
+//   - apiKey: The Steam API key to authenticate requests.
+// Returns the client, using the configured Steam Web API base URL.
+func NewHTTPSteamClient(client *http.Client, apiKey string) *HTTPSteamClient {
+	return &HTTPSteamClient{Client: client, APIKey: apiKey, API: steamAPI}
+}
+
+// GetOwnedGames fetches the games owned by the user from IPlayerService/GetOwnedGames.
+// Rate limiting (429) and server errors (5xx) are retried with back-off up to maxAPIAttempts times.
+// Arguments:
+//   - ctx: The context bounding the request including retries.
+//   - steamID64: The user's SteamID64.
+// Returns the games in API order and an error if the request fails or the response is invalid.
+func (c *HTTPSteamClient) GetOwnedGames(ctx context.Context, steamID64 string) ([]Game, error) {
+	apiURL := c.API.endpoint("IPlayerService/GetOwnedGames/v1/", url.Values{
+		"key":                       {c.APIKey},
+		"steamid":                   {steamID64},
+		"include_appinfo":           {"1"},
+		"include_played_free_games": {"1"},
+	})
+
+	var games []Game
+	err := withRetry(ctx, maxAPIAttempts, func() error {
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return permanent(fmt.Errorf("creating request: %w", err))
+		}
+
+		resp, err := c.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("fetching games: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return fmt.Errorf("Steam API returned %s", resp.Status)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return permanent(fmt.Errorf("Steam API returned %s", resp.Status))
+		}
+
+		var apiResp APIResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+			return permanent(fmt.Errorf("invalid response from Steam API: %w", err))
+		}
+		games = apiResp.Response.Games
+		if len(games) < apiResp.Response.GameCount {
+			// GetOwnedGames has no paging parameters; for some large libraries
+			// Steam silently truncates the list, so at least make it visible.
+			loggerFrom(ctx).Warn("Steam returned fewer games than it reported", "game_count", apiResp.Response.GameCount, "returned", len(games))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// checkHealth verifies that the Steam Web API can be reached at all.
+// Any HTTP response counts as reachable; only network failures are reported.
+// Arguments:
+//   - ctx: The context for the request; callers should give it a short timeout.
+//   - client: The HTTP client used for the request.
+//   - baseURL: The Steam Web API base URL.
+// Returns an error if no response arrives.
+func checkHealth(ctx context.Context, client *http.Client, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach the Steam Web API at %s: %w", baseURL, err)
+	}
+	resp.Body.Close()
+	return nil
+}
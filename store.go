@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// storeCacheTTL is how long fetched store details are reused; genres and categories rarely change.
+const storeCacheTTL = 30 * 24 * time.Hour
+
+// storeCacheVersion is stored with every cache entry and bumped whenever GameDetails gains a field,
+// so that entries written by older versions are fetched again.
+const storeCacheVersion = 2
+
+// Category is a Steam store category of a game, e.g. "Single-player" or "Co-op".
+type Category struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+}
+
+// GameDetails holds the store information about a game that the filters need.
+type GameDetails struct {
+	AppID      int        `json:"appid"`
+	Genres     []string   `json:"genres"`
+	Categories []Category `json:"categories"`
+	// Developer lists the developers as shown on the store page, comma separated.
+	Developer string `json:"developer"`
+	// Type is the store type of the app, e.g. "game" or "dlc".
+	Type string `json:"type"`
+}
+
+// storeCacheEntry is the on-disk form of the store details of one game.
+type storeCacheEntry struct {
+	Version   int         `json:"version"`
+	FetchedAt time.Time   `json:"fetched_at"`
+	Details   GameDetails `json:"details"`
+}
+
+// appDetailsResponse represents one entry of the store appdetails response,
+// which is keyed by app ID.
+type appDetailsResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Type   string `json:"type"`
+		Genres []struct {
+			Description string `json:"description"`
+		} `json:"genres"`
+		Categories []Category `json:"categories"`
+		Developers []string   `json:"developers"`
+	} `json:"data"`
+}
+
+// fetchGameDetails fetches the store details of a game from the Steam store appdetails endpoint.
+// Arguments:
+//   - ctx: The context for the request.
+//   - client: The HTTP client used for the request.
+//   - apiKey: The Steam API key; the public store endpoint does not require it.
+//   - appID: The app ID of the game.
+// Returns the game details and an error if the request fails or the store has no data for the game.
+func fetchGameDetails(ctx context.Context, client *http.Client, apiKey string, appID int) (GameDetails, error) {
+	apiURL := steamAPI.storeEndpoint("api/appdetails", url.Values{"appids": {strconv.Itoa(appID)}})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return GameDetails{}, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return GameDetails{}, fmt.Errorf("fetching app details: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return GameDetails{}, fmt.Errorf("Steam store returned %s", resp.Status)
+	}
+
+	var apiResp map[string]appDetailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return GameDetails{}, fmt.Errorf("invalid response from Steam store: %w", err)
+	}
+	entry, ok := apiResp[strconv.Itoa(appID)]
+	if !ok || !entry.Success {
+		return GameDetails{}, fmt.Errorf("no store details for app %d", appID)
+	}
+
+	details := GameDetails{
+		AppID:      appID,
+		Categories: entry.Data.Categories,
+		Developer:  strings.Join(entry.Data.Developers, ", "),
+		Type:       entry.Data.Type,
+	}
+	for _, genre := range entry.Data.Genres {
+		details.Genres = append(details.Genres, genre.Description)
+	}
+	return details, nil
+}
+
+// fetchCategories fetches the store categories of a game.
+// Arguments:
+//   - ctx: The context for the request.
+//   - client: The HTTP client used for the request.
+//   - appID: The app ID of the game.
+// Returns the categories and an error if the request fails or the store has no data for the game.
+func fetchCategories(ctx context.Context, client *http.Client, appID int) ([]Category, error) {
+	details, err := fetchGameDetails(ctx, client, "", appID)
+	if err != nil {
+		return nil, err
+	}
+	return details.Categories, nil
+}
+
+// fetchAllGameDetails fetches the store details of every game, at most perGameRateLimit requests per second.
+// Games whose details cannot be fetched are logged and left out of the result.
+// Arguments:
+//   - ctx: The context bounding all requests.
+//   - client: The HTTP client used for the requests.
+//   - apiKey: The Steam API key.
+//   - games: The games to fetch details for.
+// Returns the details keyed by app ID and an error if the context is cancelled.
+func fetchAllGameDetails(ctx context.Context, client *http.Client, apiKey string, games []Game) (map[int]GameDetails, error) {
+	details := make(map[int]GameDetails, len(games))
+	limiter := NewRateLimiter(perGameRateLimit)
+	defer limiter.Stop()
+
+	for i, game := range games {
+		if err := limiter.Wait(ctx); err != nil {
+			return details, err
+		}
+		infof(os.Stderr, "\rFetching store details %d/%d...", i+1, len(games))
+		d, err := fetchGameDetails(ctx, client, apiKey, game.AppID)
+		if err != nil {
+			loggerFrom(ctx).Warn("skipping game", "game", game.Name, "err", err)
+			continue
+		}
+		details[game.AppID] = d
+	}
+	infof(os.Stderr, "\n")
+	return details, nil
+}
+
+// filterByGenre returns the games whose store details list the given genre, ignoring case.
+// Games without details are left out.
+// Arguments:
+//   - games: The games to filter.
+//   - details: The store details keyed by app ID.
+//   - genre: The genre to look for, e.g. "RPG".
+// Returns the matching games in their original order.
+func filterByGenre(games []Game, details map[int]GameDetails, genre string) []Game {
+	filtered := make([]Game, 0)
+	for _, game := range games {
+		for _, g := range details[game.AppID].Genres {
+			if strings.EqualFold(g, genre) {
+				filtered = append(filtered, game)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// filterByCategory returns the games whose store details list the given category, ignoring case.
+// Games without details are left out.
+// Arguments:
+//   - games: The games to filter.
+//   - details: The store details keyed by app ID.
+//   - category: The category to look for, e.g. "Multi-player".
+// Returns the matching games in their original order.
+func filterByCategory(games []Game, details map[int]GameDetails, category string) []Game {
+	filtered := make([]Game, 0)
+	for _, game := range games {
+		for _, c := range details[game.AppID].Categories {
+			if strings.EqualFold(c.Description, category) {
+				filtered = append(filtered, game)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// getGamesByDeveloper returns the games whose store details list a developer containing
+// the given name, ignoring case, so "supergiant" matches "Supergiant Games".
+// Games without details are left out.
+// Arguments:
+//   - games: The games to filter.
+//   - details: The store details keyed by app ID.
+//   - developer: The developer name to look for.
+// Returns the matching games in their original order.
+func getGamesByDeveloper(games []Game, details map[int]GameDetails, developer string) []Game {
+	developer = strings.ToLower(developer)
+	filtered := make([]Game, 0)
+	for _, game := range games {
+		d, ok := details[game.AppID]
+		if ok && d.Developer != "" && strings.Contains(strings.ToLower(d.Developer), developer) {
+			filtered = append(filtered, game)
+		}
+	}
+	return filtered
+}
+
+// isDLC reports whether the store type of an app is downloadable content.
+// The store cache is consulted first; on a miss the app's details are fetched from the store,
+// bounded by apiTimeout, and added to the cache. Apps whose details cannot be fetched are assumed to be games.
+// Arguments:
+//   - appID: The app ID to check.
+// Returns true if the app is a DLC.
+func isDLC(appID int) bool {
+	cached := loadCachedGameDetails()
+	if details, ok := cached[appID]; ok {
+		return details.Type == "dlc"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	details, err := fetchGameDetails(ctx, httpClient, "", appID)
+	if err != nil {
+		return false
+	}
+	if path, err := getStoreCacheFilePath(); err == nil {
+		if cached == nil {
+			cached = make(map[int]GameDetails)
+		}
+		cached[appID] = details
+		// The cache only saves requests, so a failed write is not worth reporting.
+		_ = saveStoreCache(path, cached)
+	}
+	return details.Type == "dlc"
+}
+
+// removeDLC returns the games whose store details do not mark them as downloadable content.
+// Games without details are kept.
+// Arguments:
+//   - games: The games to filter.
+//   - details: The store details keyed by app ID.
+// Returns the remaining games in their original order.
+func removeDLC(games []Game, details map[int]GameDetails) []Game {
+	filtered := make([]Game, 0, len(games))
+	for _, game := range games {
+		if details[game.AppID].Type != "dlc" {
+			filtered = append(filtered, game)
+		}
+	}
+	return filtered
+}
+
+// loadCachedGameDetails returns the store details in the store cache without fetching anything.
+// Arguments:
+//   - None
+// Returns the cached details keyed by app ID; empty if there is no readable cache.
+func loadCachedGameDetails() map[int]GameDetails {
+	path, err := getStoreCacheFilePath()
+	if err != nil {
+		return nil
+	}
+	details, err := loadStoreCache(path)
+	if err != nil {
+		return nil
+	}
+	return details
+}
+
+// getStoreCacheFilePath returns the path of the store details cache, shared by all profiles.
+// Arguments:
+//   - None
+// Returns the path and an error if the home directory cannot be determined.
+func getStoreCacheFilePath() (string, error) {
+	home, err := storage.homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".wsipn_store_cache.json"), nil
+}
+
+// loadStoreCache reads the store details cached at path that are younger than storeCacheTTL
+// and were written with the current storeCacheVersion.
+// Arguments:
+//   - path: The cache file.
+// Returns the details keyed by app ID and an error if the file cannot be read or parsed.
+func loadStoreCache(path string) (map[int]GameDetails, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[int]storeCacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid store cache file: %w", err)
+	}
+	details := make(map[int]GameDetails, len(entries))
+	for appID, entry := range entries {
+		if entry.Version == storeCacheVersion && time.Since(entry.FetchedAt) < storeCacheTTL {
+			details[appID] = entry.Details
+		}
+	}
+	return details, nil
+}
+
+// saveStoreCache writes the given store details to path, stamped with the current time.
+// Arguments:
+//   - path: The cache file.
+//   - details: The details keyed by app ID.
+// Returns an error if the cache cannot be encoded or written.
+func saveStoreCache(path string, details map[int]GameDetails) error {
+	now := time.Now()
+	entries := make(map[int]storeCacheEntry, len(details))
+	for appID, d := range details {
+		entries[appID] = storeCacheEntry{Version: storeCacheVersion, FetchedAt: now, Details: d}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("encoding store cache: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// fetchAllGameDetailsCached is fetchAllGameDetails backed by the store cache:
+// only games without fresh cached details are requested from the store.
+// Arguments:
+//   - ctx: The context bounding all requests.
+//   - client: The HTTP client used for the requests.
+//   - apiKey: The Steam API key.
+//   - games: The games to fetch details for.
+// Returns the details keyed by app ID and an error if the context is cancelled.
+func fetchAllGameDetailsCached(ctx context.Context, client *http.Client, apiKey string, games []Game) (map[int]GameDetails, error) {
+	path, err := getStoreCacheFilePath()
+	if err != nil {
+		return fetchAllGameDetails(ctx, client, apiKey, games)
+	}
+	cached, err := loadStoreCache(path)
+	if err != nil {
+		cached = make(map[int]GameDetails)
+	}
+
+	var missing []Game
+	for _, game := range games {
+		if _, ok := cached[game.AppID]; !ok {
+			missing = append(missing, game)
+		}
+	}
+	if len(missing) == 0 {
+		return cached, nil
+	}
+
+	fetched, err := fetchAllGameDetails(ctx, client, apiKey, missing)
+	for appID, d := range fetched {
+		cached[appID] = d
+	}
+	if len(fetched) > 0 {
+		if err := saveStoreCache(path, cached); err != nil {
+			loggerFrom(ctx).Warn("could not save store cache", "err", err)
+		}
+	}
+	return cached, err
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gameItem adapts a Game to the bubbles list.
+type gameItem struct {
+	game Game
+	unit string
+}
+
+func (i gameItem) Title() string { return i.game.Name }
+
+func (i gameItem) Description() string { return formatPlaytime(i.game.PlaytimeForever, i.unit) }
+
+func (i gameItem) FilterValue() string { return i.game.Name }
+
+// gamePicker is the bubbletea model of the --interactive game list.
+type gamePicker struct {
+	list     list.Model
+	selected *Game
+}
+
+// Init implements tea.Model.
+// Arguments:
+//   - None
+// Returns no initial command.
+func (m gamePicker) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles a message: Enter selects the highlighted game, Escape or Ctrl-C quits without
+// a selection, everything else (arrow keys, / to filter) is handled by the list.
+// Arguments:
+//   - msg: The message to handle.
+// Returns the updated model and the next command.
+func (m gamePicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// While the filter is being typed, Enter and Escape belong to the filter input.
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		switch msg.String() {
+		case "enter":
+			if item, ok := m.list.SelectedItem().(gameItem); ok {
+				game := item.game
+				m.selected = &game
+			}
+			return m, tea.Quit
+		case "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height)
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View implements tea.Model.
+// Arguments:
+//   - None
+// Returns the rendered list.
+func (m gamePicker) View() string {
+	return m.list.View()
+}
+
+// newGamePicker creates the model of the interactive game list.
+// Arguments:
+//   - games: The games to list, in display order.
+//   - unit: The unit used to display playtime, "hours" or "minutes".
+// Returns the model.
+func newGamePicker(games []Game, unit string) gamePicker {
+	items := make([]list.Item, len(games))
+	for i, game := range games {
+		items[i] = gameItem{game: game, unit: unit}
+	}
+	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "What should I play next?"
+	return gamePicker{list: l}
+}
+
+// pickGameInteractively shows the games in a scrollable terminal list and lets the user pick one.
+// The list is drawn on stderr so that only the selection ends up on stdout.
+// Arguments:
+//   - games: The games to list, in display order.
+//   - unit: The unit used to display playtime, "hours" or "minutes".
+// Returns the selected game, or nil if the user quit without selecting, and an error if the
+// terminal UI fails.
+func pickGameInteractively(games []Game, unit string) (*Game, error) {
+	if len(games) == 0 {
+		return nil, errors.New("no games to choose from")
+	}
+	program := tea.NewProgram(newGamePicker(games, unit), tea.WithAltScreen(), tea.WithOutput(os.Stderr))
+	final, err := program.Run()
+	if err != nil {
+		return nil, err
+	}
+	return final.(gamePicker).selected, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// fetchOwnedGames fetches the library once, bounded by apiTimeout.
+// Arguments:
+//   - ctx: The parent context.
+//   - client: The SteamClient used to fetch the games.
+//   - steamID64: The user's SteamID64.
+// Returns the games and an error if the request fails.
+func fetchOwnedGames(ctx context.Context, client SteamClient, steamID64 string) ([]Game, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+	return client.GetOwnedGames(ctx, steamID64)
+}
+
+// watchNewGames re-fetches the library every interval and calls onNew for every game
+// whose app ID was not in the previous snapshot. Failed fetches are logged and retried
+// at the next interval against the last good snapshot.
+// Arguments:
+//   - ctx: The context; watching stops when it is done.
+//   - client: The SteamClient used to fetch the games.
+//   - steamID64: The user's SteamID64.
+//   - interval: The time between two fetches.
+//   - onNew: Called for each newly detected game, in library order.
+// Returns ctx.Err() when watching stops, or an error if the initial snapshot cannot be fetched.
+func watchNewGames(ctx context.Context, client SteamClient, steamID64 string, interval time.Duration, onNew func(Game)) error {
+	games, err := fetchOwnedGames(ctx, client, steamID64)
+	if err != nil {
+		return fmt.Errorf("could not fetch the library: %w", err)
+	}
+	known := make(map[int]bool, len(games))
+	for _, game := range games {
+		known[game.AppID] = true
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		games, err := fetchOwnedGames(ctx, client, steamID64)
+		if err != nil {
+			loggerFrom(ctx).Warn("could not refresh the library", "err", err)
+			continue
+		}
+		for _, game := range games {
+			if !known[game.AppID] {
+				known[game.AppID] = true
+				onNew(game)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// maxWishlistPages bounds how many pages of wishlist data are requested.
+const maxWishlistPages = 50
+
+// WishlistGame is a game on a user's Steam wishlist.
+type WishlistGame struct {
+	AppID    int    `json:"appid"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+}
+
+// wishlistEntry represents one entry of the store wishlistdata response, which is keyed by app ID.
+type wishlistEntry struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+}
+
+// fetchWishlist fetches the public wishlist of a user from the Steam store wishlistdata endpoint,
+// following its pages until an empty one is returned.
+// Arguments:
+//   - ctx: The context bounding all requests.
+//   - client: The HTTP client used for the requests.
+//   - steamID64: The user's SteamID64.
+// Returns the wishlist ordered by priority (0, meaning unranked, last) and an error if a request fails
+// or the wishlist is private.
+func fetchWishlist(ctx context.Context, client *http.Client, steamID64 string) ([]WishlistGame, error) {
+	wishlist := make([]WishlistGame, 0)
+	for page := 0; page < maxWishlistPages; page++ {
+		apiURL := steamAPI.storeEndpoint("wishlist/profiles/"+steamID64+"/wishlistdata/", url.Values{
+			"p": {strconv.Itoa(page)},
+		})
+
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("fetching wishlist: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("Steam store returned %s", resp.Status)
+		}
+
+		// A private wishlist is reported as {"success": 2}, the last page as an empty array.
+		var raw json.RawMessage
+		err = json.NewDecoder(resp.Body).Decode(&raw)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid response from Steam store: %w", err)
+		}
+		if len(raw) > 0 && raw[0] == '[' {
+			break
+		}
+		var status struct {
+			Success int `json:"success"`
+		}
+		if json.Unmarshal(raw, &status) == nil && status.Success == 2 {
+			return nil, fmt.Errorf("wishlist of %s is private", steamID64)
+		}
+		var entries map[string]wishlistEntry
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, fmt.Errorf("invalid response from Steam store: %w", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+		for id, entry := range entries {
+			appID, err := strconv.Atoi(id)
+			if err != nil {
+				continue
+			}
+			wishlist = append(wishlist, WishlistGame{AppID: appID, Name: entry.Name, Priority: entry.Priority})
+		}
+	}
+
+	sort.Slice(wishlist, func(i, j int) bool {
+		a, b := wishlist[i], wishlist[j]
+		if (a.Priority == 0) != (b.Priority == 0) {
+			return b.Priority == 0
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.Name < b.Name
+	})
+	return wishlist, nil
+}
+
+// wishlistToGames converts wishlist items into games for the selection.
+// None of them is owned yet, so they all have no playtime.
+// Arguments:
+//   - wishlist: The wishlist items.
+// Returns the games in wishlist order.
+func wishlistToGames(wishlist []WishlistGame) []Game {
+	games := make([]Game, 0, len(wishlist))
+	for _, item := range wishlist {
+		games = append(games, Game{AppID: item.AppID, Name: item.Name})
+	}
+	return games
+}
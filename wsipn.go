@@ -2,32 +2,103 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"math"
 	"math/rand"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
-	"os/user"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
+// steamOpenIDURL is the Steam OpenID 2.0 provider endpoint used both to start
+// the login flow and to verify the assertion returned on the callback.
+const steamOpenIDURL = "https://steamcommunity.com/openid/login"
+
+// steamOpenIDIdentityPrefix starts every claimed_id that Steam asserts; the SteamID64 follows it.
+const steamOpenIDIdentityPrefix = "https://steamcommunity.com/openid/id/"
+
+// httpClient is used for all outgoing HTTP requests. Unlike http.DefaultClient it bounds
+// dialing, TLS handshakes and waiting for response headers at the transport level,
+// so a stalled connection cannot outlive its request.
+var httpClient = newHTTPClient(30 * time.Second)
+
+// newHTTPClient creates an HTTP client with transport-level timeouts.
+// Arguments:
+//   - timeout: The overall time limit for a single request, including reading the body.
+// Returns the configured client.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   5 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConns:          10,
+		},
+	}
+}
+
 // Game represents a game in the Steam library
-// with its name and total playtime in minutes.
+// with its app ID, name, total playtime and playtime over the last two weeks in minutes,
+// and the Unix time it was last played (0 if never).
+// FreeToPlay is not part of the Steam API response and is filled in by markFreeToPlay.
 type Game struct {
-	Name            string `json:"name"`
-	PlaytimeForever int    `json:"playtime_forever"`
+	AppID            int    `json:"appid"`
+	Name             string `json:"name"`
+	PlaytimeForever  int    `json:"playtime_forever"`
+	PlaytimeTwoWeeks int    `json:"playtime_2weeks"`
+	RtimeLastPlayed  int64  `json:"rtime_last_played"`
+	FreeToPlay       bool   `json:"free_to_play"`
+}
+
+// HoursPlayed returns the total playtime of the game in hours.
+// Arguments:
+//   - None
+// Returns PlaytimeForever converted from minutes to hours.
+func (g Game) HoursPlayed() float64 {
+	return float64(g.PlaytimeForever) / 60.0
+}
+
+// DateAdded returns an approximation of when the game was added to the library, as a Unix time.
+// Steam does not report acquisition dates, so RtimeLastPlayed is used as a rough proxy;
+// it is 0 for games that were never played.
+// Arguments:
+//   - None
+// Returns the approximate Unix time the game was added, or 0 if unknown.
+func (g Game) DateAdded() int64 {
+	return g.RtimeLastPlayed
+}
+
+// HoursString returns the total playtime of the game in hours with one decimal place, e.g. "1.5h".
+// Arguments:
+//   - None
+// Returns the formatted playtime.
+func (g Game) HoursString() string {
+	return fmt.Sprintf("%.1fh", g.HoursPlayed())
 }
 
 // APIResponse represents the structure of the response from the Steam API
@@ -39,40 +110,60 @@ type APIResponse struct {
 	} `json:"response"`
 }
 
-// getSteamIDFilePath returns the file path where the SteamID64 is stored.
-// It uses the user's home directory and a fixed filename ".steamid".
+// getProfilesDir returns the directory where the SteamID64 of each profile is stored.
+// It uses the user's home directory (or --config-path) and a fixed path ".wsipn/profiles".
 // Arguments:
 //   - None
-// Returns the file path as a string and an error if the home directory cannot be determined.
-func getSteamIDFilePath() (string, error) {
-	usr, err := user.Current()
+// Returns the directory path as a string and an error if the home directory cannot be determined.
+func getProfilesDir() (string, error) {
+	home, err := storage.homeDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(usr.HomeDir, ".steamid"), nil
+	return filepath.Join(home, ".wsipn", "profiles"), nil
 }
 
+// getSteamIDFilePath returns the file path where the SteamID64 of a profile is stored.
+// Each profile is a file named after the profile inside the profiles directory.
+// Arguments:
+//   - profile: The profile name.
+// Returns the file path as a string and an error if the profile name is invalid
+// or the home directory cannot be determined.
+func getSteamIDFilePath(profile string) (string, error) {
+	if profile == "" || profile == "." || profile == ".." || strings.ContainsAny(profile, `/\`) {
+		return "", fmt.Errorf("invalid profile name %q", profile)
+	}
+	dir, err := getProfilesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, profile), nil
+}
 
-// saveSteamID64 saves the given SteamID64 to a file in the user's home directory.
+// saveSteamID64 saves the given SteamID64 to the profile's file, creating the profiles directory if needed.
 // The file is created with permissions 0600 (read/write for the owner only).
 // Arguments:
+//   - profile: The profile name.
 //   - steamID64: The SteamID64 to save.
 // Returns an error if the file cannot be written.
-func saveSteamID64(steamID64 string) error {	
-	path, err := getSteamIDFilePath()
+func saveSteamID64(profile, steamID64 string) error {
+	path, err := getSteamIDFilePath(profile)
 	if err != nil {
 		return err
 	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
 	return os.WriteFile(path, []byte(steamID64), 0600)
 }
 
-// loadSteamID64 reads the SteamID64 from the file in the user's home directory.
+// loadSteamID64 reads the SteamID64 from the profile's file.
 // It returns an error if the file does not exist or if the content is empty.
 // Arguments:
-//   - None
+//   - profile: The profile name.
 // Returns the SteamID64 as a string and an error if the file cannot be read or if the content is empty.
-func loadSteamID64() (string, error) {
-	path, err := getSteamIDFilePath()
+func loadSteamID64(profile string) (string, error) {
+	path, err := getSteamIDFilePath(profile)
 	if err != nil {
 		return "", err
 	}
@@ -84,22 +175,79 @@ func loadSteamID64() (string, error) {
 	if id == "" {
 		return "", errors.New("stored steamid is empty")
 	}
+	if err := validateSteamID64(id); err != nil {
+		return "", fmt.Errorf("stored steamid is invalid: %w", err)
+	}
 	return id, nil
 }
 
-// deleteSteamID64 deletes the file containing the SteamID64 from the user's home directory.
+// minSteamID64 is the smallest SteamID64 of an individual account in the public universe.
+// Its upper 32 bits hold the universe and account type shared by every such account.
+const minSteamID64 = 76561197960265728
+
+// validateSteamID64 checks that the given string looks like a valid SteamID64:
+// exactly 17 digits with the universe and account type of an individual public account.
+// Arguments:
+//   - id: The SteamID64 to validate.
+// Returns an error describing why the ID is invalid, or nil if it is valid.
+func validateSteamID64(id string) error {
+	if len(id) != 17 {
+		return fmt.Errorf("steamid %q must be 17 digits long", id)
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("steamid %q must contain only digits", id)
+		}
+	}
+	value, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("steamid %q is out of range: %w", id, err)
+	}
+	if value>>32 != minSteamID64>>32 {
+		return fmt.Errorf("steamid %q is outside the individual account range", id)
+	}
+	return nil
+}
+
+// deleteSteamID64 deletes the profile's file containing the SteamID64.
 // It returns an error if the file cannot be removed.
 // Arguments:
-//   - None
+//   - profile: The profile name.
 // Returns an error if the file cannot be deleted.
-func deleteSteamID64() error {
-	path, err := getSteamIDFilePath()
+func deleteSteamID64(profile string) error {
+	path, err := getSteamIDFilePath(profile)
 	if err != nil {
 		return err
 	}
 	return os.Remove(path)
 }
 
+// listProfiles returns the names of all saved profiles, sorted alphabetically.
+// A missing profiles directory is not an error and yields no profiles.
+// Arguments:
+//   - None
+// Returns the profile names and an error if the directory cannot be read.
+func listProfiles() ([]string, error) {
+	dir, err := getProfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	return profiles, nil
+}
+
 // getFreePort finds a free TCP port on the local machine.
 // It listens on a random port and returns the port number as a string.
 // Arguments:
@@ -116,6 +264,10 @@ func getFreePort() (string, error) {
 	return parts[len(parts)-1], nil
 }
 
+// BrowserOpener opens a URI in a web browser. openBrowser is the real implementation;
+// tests pass a no-op so the login path does not launch a browser.
+type BrowserOpener func(uri string) error
+
 // openBrowser opens the given URI in the default web browser.
 // It uses different commands based on the operating system:
 // Arguments:
@@ -154,175 +306,2284 @@ func promptYesNo(message string) bool {
 }
 
 // main is the entry point of the program.
-// It loads the Steam API key from the environment or .env file,
-// checks for a saved SteamID64, prompts the user to refresh their login if desired,
-// performs OpenID login if necessary, and lists the user's games using the Steam API.
+// It dispatches to the subcommand named on the command line; without one it
+// suggests a random unplayed game as before (see run).
 func main() {
-	_ = godotenv.Load()
-	apiKey := os.Getenv("STEAM_API_KEY")
+	err := newRootCommand().Execute()
+	if errors.Is(err, errTooFewGames) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err != nil {
+		fatal("wsipn failed", "err", err)
+	}
+}
+
+// configureRuntime installs the HTTP logging transport and the retry budget described by the options.
+// The transport is wrapped only once; a later call just updates its logger and --verbose output.
+// Arguments:
+//   - opts: The parsed command-line options.
+// Returns the logger for the options, to be passed on with withLogger.
+func configureRuntime(opts options) *slog.Logger {
+	logger := newLogger(opts.logLevel)
+	transport, ok := httpClient.Transport.(*loggingTransport)
+	if !ok {
+		transport = &loggingTransport{next: httpClient.Transport}
+		httpClient.Transport = transport
+	}
+	transport.logger = logger
+	transport.verbose = nil
+	if opts.verbose {
+		transport.verbose = os.Stderr
+	}
+	maxAPIAttempts = opts.maxRetries + 1
+	quiet = opts.quiet
+	apiTimeout = opts.timeout
+	perGameRateLimit = opts.rateLimit
+	steamAPI = opts.steamAPI
+	storage = StorageConfig{BaseDir: opts.configPath}
+	return logger
+}
+
+// requireAPIKey loads the Steam API key from the usual sources.
+// Arguments:
+//   - ctx: The context carrying the logger.
+//   - opts: The parsed command-line options.
+// Returns the API key and an error if none of the sources provides one.
+func requireAPIKey(ctx context.Context, opts options) (string, error) {
+	apiKey := loadAPIKey(ctx, opts.apiKey)
 	if apiKey == "" {
-		log.Fatal("STEAM_API_KEY not set in environment or .env file")
+		return "", errors.New("Steam API key not set: use --api-key, STEAM_API_KEY in the environment or .env file, the system keychain (--keychain-save), or steam_api_key in ~/.wsipn/config.json")
 	}
+	return apiKey, nil
+}
 
-	var steamID64 string
-	steamID64, err := loadSteamID64()
-	if err == nil {
-		fmt.Println("✔️ Found saved SteamID64:", steamID64)
-		if promptYesNo("Would you like to refresh your Steam login? (y/N): ") {
-			if err := deleteSteamID64(); err != nil {
-				log.Printf("Could not delete saved SteamID64: %v", err)
-			}
-			steamID64, err = performOpenIDLogin()
-			if err != nil {
-				log.Fatalf("Login failed: %v", err)
-			}
-			fmt.Println("✔️ Saving SteamID64 for next time:", steamID64)
-			if err := saveSteamID64(steamID64); err != nil {
-				fmt.Println("Warning: could not save SteamID64:", err)
-			}
+// loginAndSave runs the OpenID login, greets the user by persona name, saves the resulting
+// SteamID64 for the profile and drops the profile's game cache, which may belong to a different account.
+// With --no-save nothing is written or deleted.
+// Arguments:
+//   - ctx: The context for the login and requests.
+//   - opts: The parsed command-line options.
+//   - apiKey: The Steam API key used to look up the persona name; the greeting is skipped when empty.
+// Returns the SteamID64 and an error if the login fails.
+func loginAndSave(ctx context.Context, opts options, apiKey string) (string, error) {
+	logger := loggerFrom(ctx)
+	// Fail fast while offline instead of opening a browser and waiting for a login that cannot complete.
+	healthCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	err := checkHealth(healthCtx, httpClient, steamAPI.BaseURL.String())
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("check your internet connection: %w", err)
+	}
+
+	loginTimeout := time.Duration(opts.loginTimeout) * time.Minute
+	loginCtx, cancel := context.WithTimeout(ctx, loginTimeout)
+	steamID64, err := performOpenIDLogin(loginCtx, openBrowser, opts.noBrowser, opts.callbackTLS)
+	cancel()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", fmt.Errorf("login failed: no login received within %s: %w", loginTimeout, context.DeadlineExceeded)
+	}
+	if err != nil {
+		return "", fmt.Errorf("login failed: %w", err)
+	}
+	if apiKey != "" {
+		summaryCtx, cancel := context.WithTimeout(ctx, apiTimeout)
+		summary, err := getSteamUserSummary(summaryCtx, httpClient, apiKey, steamID64)
+		cancel()
+		if err != nil {
+			logger.Warn("could not fetch Steam profile", "err", err)
 		} else {
-			fmt.Println("Using saved SteamID64.")
+			infof(os.Stdout, "Welcome, %s!\n", summary.PersonaName)
 		}
-	} else {
-		steamID64, err = performOpenIDLogin()
+	}
+	if opts.noSave {
+		infof(os.Stdout, "✔️ Logged in as SteamID64 %s (not saved)\n", steamID64)
+		return steamID64, nil
+	}
+	infof(os.Stdout, "✔️ Saving SteamID64 for next time: %s\n", steamID64)
+	if err := saveSteamID64(opts.profile, steamID64); err != nil {
+		logger.Warn("could not save SteamID64", "err", err)
+	}
+	deleteCache(ctx, opts.profile)
+	return steamID64, nil
+}
+
+// resolveSteamID determines which account to use: the --steam-id of a dry run, a resolved
+// --vanity name, the profile's saved SteamID64, or a fresh OpenID login.
+// With --no-save the saved SteamID64 is ignored and the OpenID login always runs.
+// Arguments:
+//   - ctx: The context for the login and requests.
+//   - opts: The parsed command-line options.
+//   - apiKey: The Steam Web API key, used to resolve vanity names.
+//   - offerRefresh: Whether to ask before reusing a saved SteamID64.
+// Returns the SteamID64 and an error if it could not be determined.
+func resolveSteamID(ctx context.Context, opts options, apiKey string, offerRefresh bool) (string, error) {
+	if opts.dryRun {
+		infof(os.Stdout, "✔️ Dry run: using SteamID64 %s\n", opts.steamID)
+		return opts.steamID, nil
+	}
+	if opts.vanity != "" {
+		vanityCtx, cancel := context.WithTimeout(ctx, apiTimeout)
+		defer cancel()
+		steamID64, err := resolveVanityURL(vanityCtx, apiKey, opts.vanity)
 		if err != nil {
-			log.Fatalf("Login failed: %v", err)
+			return "", fmt.Errorf("could not resolve vanity URL: %w", err)
 		}
-		fmt.Println("✔️ Saving SteamID64 for next time:", steamID64)
-		if err := saveSteamID64(steamID64); err != nil {
-			fmt.Println("Warning: could not save SteamID64:", err)
+		infof(os.Stdout, "✔️ Resolved %s to SteamID64: %s\n", opts.vanity, steamID64)
+		return steamID64, nil
+	}
+	if opts.noSave {
+		return loginAndSave(ctx, opts, apiKey)
+	}
+	steamID64, err := loadSteamID64(opts.profile)
+	if err != nil {
+		return loginAndSave(ctx, opts, apiKey)
+	}
+	infof(os.Stdout, "✔️ Found saved SteamID64 for profile %q: %s\n", opts.profile, steamID64)
+	if offerRefresh && promptYesNo("Would you like to refresh your Steam login? (y/N): ") {
+		if err := deleteSteamID64(opts.profile); err != nil {
+			loggerFrom(ctx).Warn("could not delete saved SteamID64", "err", err)
 		}
+		return loginAndSave(ctx, opts, apiKey)
 	}
+	infof(os.Stdout, "Using saved SteamID64.\n")
+	return steamID64, nil
+}
 
-	if err := listGames(steamID64, apiKey); err != nil {
-		log.Fatalf("Error: %v", err)
+// effectiveCacheTTL returns how long the profile's game cache may be reused.
+// The profile cache belongs to the saved account, not a resolved, given or unsaved one.
+// Arguments:
+//   - opts: The parsed command-line options.
+// Returns the cache TTL, 0 when the cache must not be used.
+func effectiveCacheTTL(opts options) time.Duration {
+	if opts.vanity != "" || opts.dryRun || opts.noSave {
+		return 0
 	}
+	return opts.cacheTTL
 }
 
-// performOpenIDLogin initiates the OpenID login process with Steam.
+// run executes the default behavior: it resolves the account and prints a selection,
+// once or every --watch interval until interrupted.
 // Arguments:
-//   - None
-// Returns the SteamID64 as a string and an error if the login process fails.
-func performOpenIDLogin() (string, error) {
-	port, err := getFreePort()
-	if err != nil {
-		return "", fmt.Errorf("could not get free port: %v", err)
+//   - ctx: The context carrying the logger.
+//   - opts: The parsed command-line options.
+// Returns an error if the account could not be resolved or a single selection fails.
+func run(ctx context.Context, opts options) error {
+	if opts.markPlayed != 0 {
+		if err := addToSkipList(opts.markPlayed); err != nil {
+			return fmt.Errorf("could not update skip list: %w", err)
+		}
+		fmt.Printf("✔️ App %d will no longer be suggested.\n", opts.markPlayed)
+		return nil
 	}
-
-	redirectURL := fmt.Sprintf("http://localhost:%s/callback", port)
-	realmURL := fmt.Sprintf("http://localhost:%s", port)
-	loginURL := fmt.Sprintf(
-		"https://steamcommunity.com/openid/login"+
-			"?openid.ns=%s"+
-			"&openid.mode=checkid_setup"+
-			"&openid.return_to=%s"+
-			"&openid.realm=%s"+
-			"&openid.identity=%s"+
-			"&openid.claimed_id=%s",
-		url.QueryEscape("http://specs.openid.net/auth/2.0"),
-		url.QueryEscape(redirectURL),
-		url.QueryEscape(realmURL),
-		url.QueryEscape("http://specs.openid.net/auth/2.0/identifier_select"),
-		url.QueryEscape("http://specs.openid.net/auth/2.0/identifier_select"),
-	)
-
-	fmt.Println("Opening Steam login in your browser...")
-	if err := openBrowser(loginURL); err != nil {
-		fmt.Println("Cannot open browser. Please visit this URL manually:")
-		fmt.Println(loginURL)
+	if opts.unmarkPlayed != 0 {
+		if err := removeFromSkipList(opts.unmarkPlayed); err != nil {
+			return fmt.Errorf("could not update skip list: %w", err)
+		}
+		fmt.Printf("✔️ App %d can be suggested again.\n", opts.unmarkPlayed)
+		return nil
 	}
-
-	authChan := make(chan string)
-	mux := http.NewServeMux()
-	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		claimedID := r.URL.Query().Get("openid.claimed_id")
-		if claimedID == "" {
-			http.Error(w, "Missing claimed_id", http.StatusBadRequest)
-			return
+	if opts.listProfiles {
+		profiles, err := listProfiles()
+		if err != nil {
+			return fmt.Errorf("could not list profiles: %w", err)
 		}
-		parts := strings.Split(claimedID, "/")
-		steamID64 := parts[len(parts)-1]
-		fmt.Fprintln(w, "Authentication complete. You may close this window.")
-		authChan <- steamID64
-	})
+		for _, profile := range profiles {
+			fmt.Println(profile)
+		}
+		return nil
+	}
 
-	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+	apiKey, err := requireAPIKey(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if opts.keychainSave {
+		if err := saveAPIKeyToKeychain(apiKey); err != nil {
+			return fmt.Errorf("could not save API key to keychain: %w", err)
+		}
+		fmt.Println("✔️ Saved Steam API key to the system keychain.")
+		return nil
 	}
 
-	go func() {
-		if err := server.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatalf("server error: %v", err)
+	// --all-profiles uses the saved SteamID64 of every profile instead of a single account.
+	var steamID64 string
+	if !opts.allProfiles {
+		steamID64, err = resolveSteamID(ctx, opts, apiKey, !opts.statsOnly && !opts.quiet)
+		if err != nil {
+			return err
 		}
-	}()
+	}
 
-	steamID64 := <-authChan
+	cacheTTL := effectiveCacheTTL(opts)
+	steam := NewHTTPSteamClient(httpClient, apiKey)
+	if opts.watchNew > 0 {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Printf("Watching for new games every %s (press Ctrl-C to exit)\n", opts.watchNew)
+		err := watchNewGames(ctx, steam, steamID64, opts.watchNew, func(game Game) {
+			fmt.Printf("%s 🎉 New in your library: %s (app %d)\n", time.Now().Format("15:04"), game.Name, game.AppID)
+		})
+		if errors.Is(err, context.Canceled) {
+			infof(os.Stdout, "Stopped watching.\n")
+			return nil
+		}
+		return err
+	}
+	if opts.watch <= 0 {
+		return runSelection(ctx, opts, steam, apiKey, steamID64, cacheTTL)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	server.Shutdown(ctx)
-	return steamID64, nil
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(opts.watch)
+	defer ticker.Stop()
+	for {
+		if err := runSelection(ctx, opts, steam, apiKey, steamID64, cacheTTL); err != nil {
+			loggerFrom(ctx).Error("selection failed", "err", err)
+		}
+		infof(os.Stdout, "\nWatching: next update in %s (press Ctrl-C to exit)\n\n", opts.watch)
+		select {
+		case <-ctx.Done():
+			infof(os.Stdout, "Stopped watching.\n")
+			return nil
+		case <-ticker.C:
+		}
+		// Every later round must see fresh data from Steam, not the cache.
+		cacheTTL = 0
+	}
 }
 
-// listGames fetches the list of games owned by the user using the Steam API.
-// It prints the total number of games, the number of unplayed games,
-// and randomly selects one unplayed game to suggest to the user.
+// errTooFewGames is returned by runSelection when the library is smaller than --min-games.
+var errTooFewGames = errors.New("library is smaller than expected")
+
+// runSelection fetches the user's games, applies all filters and prints the report
+// or the view selected by the flags. It is run once, or repeatedly in watch mode.
 // Arguments:
+//   - ctx: The context for long running requests; cancelled on Ctrl-C in watch mode.
+//   - opts: The parsed command-line flags.
+//   - steam: The SteamClient used to fetch the games.
+//   - apiKey: The Steam API key.
 //   - steamID64: The user's SteamID64.
-//   - apiKey: The Steam API key to authenticate the request.
-// Returns an error if the API request fails or if the response is invalid.
-func listGames(steamID64, apiKey string) error {
-	apiURL := fmt.Sprintf(
-		"https://api.steampowered.com/IPlayerService/GetOwnedGames/v1/?key=%s&steamid=%s&include_appinfo=1&include_played_free_games=1",
-		apiKey, steamID64,
-	)
+//   - cacheTTL: The maximum age of a cached game list that may be reused.
+// Returns an error if the games cannot be fetched, filtered or rendered.
+func runSelection(ctx context.Context, opts options, steam SteamClient, apiKey, steamID64 string, cacheTTL time.Duration) error {
+	logger := loggerFrom(ctx)
+	if opts.diff {
+		return printPlaytimeDiff(ctx, steam, opts.profile, steamID64, opts.playtimeUnit)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	var games []Game
+	var err error
+	if opts.wishlist {
+		wishlistCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		wishlist, err := fetchWishlist(wishlistCtx, httpClient, steamID64)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("could not fetch wishlist: %w", err)
+		}
+		games = wishlistToGames(wishlist)
+	} else if opts.allProfiles {
+		games, err = listAllProfilesGames(ctx, steam, cacheTTL)
+		if err != nil {
+			return err
+		}
+	} else {
+		games, err = listGamesCached(ctx, steam, opts.profile, steamID64, cacheTTL)
+		if err != nil {
+			return err
+		}
+	}
+	if len(games) < opts.minGames {
+		return fmt.Errorf("%w: found %d games but --min-games is %d; did you log in with the right account?",
+			errTooFewGames, len(games), opts.minGames)
+	}
+	markFreeToPlay(games)
+	if opts.exportJSON != "" {
+		if err := exportGamesJSON(games, opts.exportJSON); err != nil {
+			return fmt.Errorf("could not export games: %w", err)
+		}
+	}
+	if opts.exportCSV != "" {
+		if err := exportGamesCSV(games, opts.exportCSV); err != nil {
+			return fmt.Errorf("could not export games: %w", err)
+		}
+	}
+	if opts.listAll {
+		return listAllGames(games, opts.format, opts.playtimeUnit, os.Stdin, os.Stdout)
+	}
+	if opts.search != "" {
+		found := searchGames(games, opts.search)
+		if len(found) == 0 {
+			fmt.Printf("No games match %q.\n", opts.search)
+		}
+		for _, game := range found {
+			fmt.Printf("%s (%s)\n", game.Name, formatPlaytime(game.PlaytimeForever, opts.playtimeUnit))
+		}
+		return nil
+	}
+	if opts.minPlaytime > 0 || opts.maxPlaytime > 0 {
+		games, err = getGamesInRange(games, opts.minPlaytime, opts.maxPlaytime)
+		if err != nil {
+			return fmt.Errorf("invalid playtime window: %w", err)
+		}
+	}
+	excluded := splitList(opts.exclude)
+	if fromFile, err := loadExcludeFile(); err == nil {
+		excluded = append(excluded, fromFile...)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		logger.Warn("could not read exclude file", "err", err)
+	}
+	games = excludeGames(games, excluded)
+	if opts.ignoreBeta {
+		allowed, err := loadBetaAllowFile()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Warn("could not read beta allowlist", "err", err)
+		}
+		games = removeBetaEntries(games, allowed)
+	}
+	if path, err := getSkipFilePath(); err == nil {
+		skipped, err := loadSkipList(path)
+		if err != nil {
+			logger.Warn("could not read skip list", "err", err)
+		}
+		games = removeSkipped(games, skipped)
+	}
+	if opts.ignoreFree {
+		games = removeFreeToPlay(games)
+	}
+	if opts.installedOnly {
+		path, err := findLibraryFoldersFile()
+		if err != nil {
+			return err
+		}
+		folders, err := parseLibraryFolders(path)
+		if err != nil {
+			return fmt.Errorf("could not read Steam library folders: %w", err)
+		}
+		games = filterInstalled(games, folders)
+	}
+	games = filterGamesByName(games, opts.filter)
+	if opts.minHours > 0 || opts.maxHours > 0 {
+		games, err = getGamesInRange(games, int(opts.minHours*60), int(opts.maxHours*60))
+		if err != nil {
+			return fmt.Errorf("invalid playtime range: %w", err)
+		}
+	}
+	if !opts.since.IsZero() {
+		games = filterPlayedSince(games, opts.since)
+	}
+	if opts.sinceHours > 0 {
+		games = filterByLastPlayedWithin(games, opts.sinceHours)
+	}
+	if opts.excludeDLC {
+		// Only the cached store details are consulted here; fetching them for the whole
+		// library would take one second per game. The picked games are checked with the
+		// store by pickUnplayedGames.
+		games = removeDLC(games, loadCachedGameDetails())
+	}
+	if opts.genre != "" || opts.category != "" || opts.developer != "" {
+		details, err := fetchAllGameDetailsCached(ctx, httpClient, apiKey, games)
+		if err != nil {
+			return fmt.Errorf("could not fetch store details: %w", err)
+		}
+		if opts.excludeDLC {
+			games = removeDLC(games, details)
+		}
+		if opts.genre != "" {
+			games = filterByGenre(games, details, opts.genre)
+		}
+		if opts.category != "" {
+			games = filterByCategory(games, details, opts.category)
+		}
+		if opts.developer != "" {
+			games = getGamesByDeveloper(games, details, opts.developer)
+		}
+	}
+	if opts.achievements {
+		counts, err := fetchAllAchievementCounts(ctx, httpClient, apiKey, steamID64, games)
+		if err != nil {
+			return fmt.Errorf("could not fetch achievements: %w", err)
+		}
+		games = filterNoAchievements(games, counts)
+	}
+	if len(games) == 0 {
+		infof(os.Stdout, "No games found.\n")
+		return nil
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
+	if opts.almostDone > 0 {
+		progress, err := fetchAllAchievementProgress(ctx, httpClient, apiKey, steamID64, games)
+		if err != nil {
+			return fmt.Errorf("could not fetch achievements: %w", err)
+		}
+		almost := getAlmostCompletedGames(progress, opts.almostDone/100)
+		sort.SliceStable(almost, func(i, j int) bool {
+			return almost[i].AchievementsUnlocked*almost[j].AchievementsTotal > almost[j].AchievementsUnlocked*almost[i].AchievementsTotal
+		})
+		fmt.Printf("== Almost Done (at least %g%% of achievements) ==\n", opts.almostDone)
+		if len(almost) == 0 {
+			fmt.Println("No games are that close to completion.")
+		}
+		for i, game := range almost {
+			fmt.Printf("%2d. %s (%d/%d achievements)\n", i+1, game.Name, game.AchievementsUnlocked, game.AchievementsTotal)
+		}
+		return nil
 	}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("fetching games: %w", err)
+	if opts.newDays > 0 {
+		added := listRecentlyAdded(games, opts.newDays)
+		fmt.Printf("== Probably Added in the Last %d Days ==\n", opts.newDays)
+		if len(added) == 0 {
+			fmt.Println("No games that came out on Steam recently enough.")
+		}
+		for _, game := range added {
+			fmt.Printf("%s (on Steam since about %s)\n", game.Name, estimateAddedDate(game.AppID).Format("2006-01"))
+		}
+		return nil
 	}
-	defer resp.Body.Close()
 
-	var apiResp APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return fmt.Errorf("invalid response from Steam API: %w", err)
+	if opts.recentlyPlayed {
+		recent := recentlyPlayedGames(games, 10)
+		fmt.Printf("== Recently Played (last two weeks) ==\n")
+		if len(recent) == 0 {
+			fmt.Println("No games played in the last two weeks.")
+		}
+		for i, game := range recent {
+			fmt.Printf("%2d. %s (%s)\n", i+1, game.Name, formatPlaytime(game.PlaytimeTwoWeeks, opts.playtimeUnit))
+		}
+		return nil
 	}
-	if len(apiResp.Response.Games) == 0 {
-		fmt.Println("No games found.")
+
+	if opts.histogram {
+		fmt.Printf("== Playtime Histogram ==\n")
+		fmt.Print(renderHistogram(groupByPlaytimeBucket(games, 60), playtimeBucketLabels(60), terminalWidth()))
 		return nil
 	}
-	games := apiResp.Response.Games
-	sort.Slice(games, func(i, j int) bool {
-		return games[i].Name < games[j].Name
-	})
 
-	unplayedGames := make([]string, 0)
-	for _, game := range games {
-		if game.PlaytimeForever == 0 {
-			unplayedGames = append(unplayedGames, game.Name)
+	if opts.bucketStats {
+		averages := getAveragePlaytimeByBucket(games, defaultStatBuckets)
+		fmt.Printf("== Average Playtime by Range (playtime in minutes) ==\n")
+		for i := range defaultStatBuckets {
+			label := bucketRangeLabel(defaultStatBuckets, i)
+			if mean, ok := averages[label]; ok {
+				fmt.Printf("%-10s %s\n", label, formatPlaytime(int(math.Round(mean)), opts.playtimeUnit))
+			}
 		}
+		return nil
 	}
 
-	fmt.Printf("== Welcome to WSIPN 1.0 ==\n")
-	fmt.Printf("Total games: %d, Unplayed games: %d\n", len(games), len(unplayedGames))
-	fmt.Printf("No playtime recorded for these games:\n")
-	for _, name := range unplayedGames {
-		fmt.Printf("%s\n", name)
+	if opts.compareSteamID != "" {
+		other, err := listGames(ctx, steam, opts.compareSteamID)
+		if err != nil {
+			return fmt.Errorf("could not fetch library of %s (is the profile public?): %w", opts.compareSteamID, err)
+		}
+		both, onlyYou, onlyThem := compareLibraries(games, other)
+		for _, section := range []struct {
+			title string
+			games []Game
+		}{
+			{"Both Own", both},
+			{"Only You Own", onlyYou},
+			{"Only " + opts.compareSteamID + " Owns", onlyThem},
+		} {
+			fmt.Printf("== %s (%d) ==\n", section.title, len(section.games))
+			for _, game := range section.games {
+				fmt.Println(game.Name)
+			}
+			fmt.Println()
+		}
+		return nil
+	}
+	if opts.friendsPlay != "" {
+		return printFriendRecommendations(ctx, steam, apiKey, opts.friendsPlay, games, opts.thresholdHours, opts.playtimeUnit)
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	randomIndex := rand.Intn(len(unplayedGames))
-	fmt.Printf("\n== Random Game Selection ==\n")
-	fmt.Printf("Randomly selected game to play: %s\n", unplayedGames[randomIndex])
-	return nil
-}
-// Note: The above code assumes that the .env file is properly set up with the STEAM_API_KEY.
\ No newline at end of file
+	thresholdMinutes := int(math.Round(opts.thresholdHours * 60))
+	if opts.statsOnly {
+		// games is not empty here, so this cannot fail.
+		stats, _ := getPlaytimeStats(games)
+		fmt.Printf("== Library Statistics ==\n")
+		fmt.Printf("Games: %d\n", len(games))
+		fmt.Printf("%.1f%% of your library is unplayed\n", getUnplayedPercent(games, thresholdMinutes))
+		fmt.Printf("Total playtime: %s\n", formatPlaytime(stats.Total, opts.playtimeUnit))
+		fmt.Printf("Mean playtime: %s\n", formatPlaytime(int(math.Round(stats.Mean)), opts.playtimeUnit))
+		fmt.Printf("Median playtime: %s\n", formatPlaytime(int(math.Round(stats.Median)), opts.playtimeUnit))
+		return nil
+	}
+
+	unplayed := unplayedGamesWithThreshold(games, opts.thresholdHours)
+	if opts.shuffle {
+		shuffled := shuffleGames(unplayed, newSelectionRand(opts))
+		if opts.countSet && opts.count < len(shuffled) {
+			shuffled = shuffled[:opts.count]
+		}
+		for _, game := range shuffled {
+			fmt.Println(game.Name)
+		}
+		return nil
+	}
+	if opts.interactive {
+		sorted, err := sortGames(unplayed, opts.sortBy)
+		if err != nil {
+			return err
+		}
+		selected, err := pickGameInteractively(sorted, opts.playtimeUnit)
+		if err != nil {
+			return fmt.Errorf("interactive mode failed: %w", err)
+		}
+		if selected != nil {
+			fmt.Println(selected.Name)
+		}
+		return nil
+	}
+	report := Report{
+		TotalGames:      len(games),
+		Threshold:       thresholdMinutes,
+		UnplayedPercent: getUnplayedPercent(games, thresholdMinutes),
+		PlaytimeUnit:    opts.playtimeUnit,
+		InactiveDays:    opts.inactiveDays,
+		TotalPlaytime:   getTotalPlaytime(games),
+	}
+	if opts.inactiveDays > 0 {
+		report.InactiveCount = len(getGamesWithNoRecentActivity(games, opts.inactiveDays))
+	}
+	report.Unplayed, err = sortGames(unplayed, opts.sortBy)
+	if err != nil {
+		return err
+	}
+	// games is not empty here, so these cannot fail.
+	report.Stats, _ = getPlaytimeStats(games)
+	report.LeastPlayed, _ = getLeastPlayedGame(games)
+	report.MostPlayed, _ = getMostPlayedGame(games)
+	if streak, err := getStreakGame(games); err == nil {
+		report.Streak = &streak
+	}
+	if almost, err := getCompletionistGame(games); err == nil {
+		report.AlmostThere = &almost
+	}
+	if neglected, err := getNeglectedGame(unplayed); err == nil {
+		report.Neglected = &neglected
+	}
+	if longest, err := getLongestUnplayed(games); err == nil {
+		report.LongestUnplayed = &longest
+	}
+	if favourite, err := getAllTimeFavourite(games); err == nil {
+		report.Favourite = &favourite
+	}
+	if opts.percentileSet {
+		atPercentile, err := getTopPercentileGame(games, opts.percentile)
+		if err != nil {
+			return err
+		}
+		report.AtPercentile = &atPercentile
+		report.Percentile = opts.percentile
+	}
+	if opts.playtimeGoal > 0 {
+		goalMinutes := int(math.Round(opts.playtimeGoal * 60))
+		if closest, err := getGameClosestToGoal(games, goalMinutes); err == nil {
+			report.ClosestToGoal = &closest
+			report.GoalMinutes = goalMinutes
+		}
+	}
+
+	topN := opts.topN
+	if !opts.topNSet && topN > len(games) {
+		// Without an explicit --top-n a small library simply shows every game.
+		topN = len(games)
+	}
+	report.TopPlayed, err = topNGames(games, topN)
+	if err != nil {
+		return fmt.Errorf("invalid --top-n: %w", err)
+	}
+
+	if len(unplayed) > 0 {
+		rng := newSelectionRand(opts)
+
+		pool := unplayed
+		historyPath, historyErr := getHistoryFilePath()
+		if historyErr == nil && opts.historySize > 0 {
+			recent, err := loadHistory(historyPath, opts.historySize)
+			if err != nil {
+				logger.Warn("could not read selection history", "err", err)
+			}
+			// Only skip recent picks while something else is left to suggest.
+			if fresh := excludeGames(unplayed, recent); len(fresh) > 0 {
+				pool = fresh
+			}
+		}
+
+		report.RandomUnplayed, err = pickUnplayedGames(opts, pool, rng)
+		if err != nil {
+			return err
+		}
+		if historyErr == nil && opts.historySize > 0 {
+			for _, game := range report.RandomUnplayed {
+				if err := appendHistory(historyPath, game.Name, opts.historySize); err != nil {
+					logger.Warn("could not update selection history", "err", err)
+					break
+				}
+			}
+		}
+		if len(report.RandomUnplayed) < opts.count {
+			infof(os.Stderr, "Warning: only %d unplayed games available, showing all of them.\n", len(report.RandomUnplayed))
+		}
+	}
+
+	if opts.quiet {
+		if err := renderSelection(os.Stdout, report.RandomUnplayed, opts.format, opts.playtimeUnit); err != nil {
+			return fmt.Errorf("could not write output: %w", err)
+		}
+	} else {
+		renderer, err := newRenderer(opts.format)
+		if err != nil {
+			return err
+		}
+		if err := renderer.Render(os.Stdout, report); err != nil {
+			return fmt.Errorf("could not write output: %w", err)
+		}
+	}
+	if opts.showImage && !opts.quiet && opts.format == "text" && len(report.RandomUnplayed) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		if err := showGameImage(ctx, os.Stdout, report.RandomUnplayed[0]); err != nil {
+			logger.Warn("could not show game image", "err", err)
+		}
+		cancel()
+	}
+	if opts.outputFile != "" {
+		if err := writeSelection(report.RandomUnplayed, opts.outputFile); err != nil {
+			return fmt.Errorf("could not write selection: %w", err)
+		}
+	}
+
+	if opts.webhookURL != "" && len(report.RandomUnplayed) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		if err := notifyWebhook(ctx, httpClient, opts.webhookURL, report.RandomUnplayed[0]); err != nil {
+			logger.Warn("could not notify webhook", "err", err)
+		}
+		cancel()
+	}
+
+	if opts.launch && len(report.RandomUnplayed) > 0 {
+		if err := launchGame(report.RandomUnplayed[0]); err != nil {
+			logger.Warn("could not launch game", "err", err)
+		}
+	}
+
+	if opts.open && len(report.RandomUnplayed) > 0 {
+		if err := openStorePage(report.RandomUnplayed[0]); err != nil {
+			logger.Warn("could not open store page", "err", err)
+		}
+	}
+	return nil
+}
+
+// notifyWebhook announces the selected game by POSTing a Discord-style
+// {"content": "..."} JSON payload to the given webhook URL.
+// Arguments:
+//   - ctx: The context for the request.
+//   - client: The HTTP client used to send the request.
+//   - webhookURL: The webhook to post to.
+//   - game: The selected game.
+// Returns an error if the request fails or the webhook does not answer with a 2xx status.
+func notifyWebhook(ctx context.Context, client *http.Client, webhookURL string, game Game) error {
+	payload, err := json.Marshal(map[string]string{
+		"content": "Tonight we play: " + game.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// launchGame starts the given game through the Steam client using a steam://run URI.
+// Arguments:
+//   - game: The game to launch.
+// Returns an error if the game has no app ID or the URI cannot be opened.
+func launchGame(game Game) error {
+	if game.AppID == 0 {
+		return fmt.Errorf("cannot launch %s: unknown app ID", game.Name)
+	}
+	infof(os.Stdout, "Launching %s...\n", game.Name)
+	return openBrowser(fmt.Sprintf("steam://run/%d", game.AppID))
+}
+
+// openStorePage opens the Steam store page of the given game in the default browser.
+// Arguments:
+//   - game: The game whose store page to open.
+// Returns an error if the game has no app ID or the browser could not be started.
+func openStorePage(game Game) error {
+	if game.AppID == 0 {
+		return fmt.Errorf("cannot open store page for %s: unknown app ID", game.Name)
+	}
+	return openBrowser(fmt.Sprintf("https://store.steampowered.com/app/%d/", game.AppID))
+}
+
+// options holds the values of the command-line flags.
+type options struct {
+	threshold      int
+	thresholdHours float64
+	exportJSON     string
+	exportCSV      string
+	recentlyPlayed bool
+	cacheTTL       time.Duration
+	topN           int
+	topNSet        bool
+	filter         string
+	count          int
+	launch         bool
+	profile        string
+	listProfiles   bool
+	exclude        string
+	loginTimeout   int
+	sortBy         string
+	vanity         string
+	apiKey         string
+	format         string
+	minPlaytime    int
+	maxPlaytime    int
+	minHours       float64
+	maxHours       float64
+	maxRetries     int
+	timeout        time.Duration
+	rateLimit      float64
+	ignoreFree     bool
+	webhookURL     string
+	seed           int64
+	seedSet        bool
+	historySize    int
+	histogram      bool
+	bucketStats    bool
+	inactiveDays   int
+	shuffle        bool
+	verbose        bool
+	wishlist       bool
+	sinceHours     float64
+	configPath     string
+	diff           bool
+	callbackTLS    bool
+	listAll        bool
+	search         string
+	installedOnly  bool
+	weighted       bool
+	interactive    bool
+	percentile     float64
+	percentileSet  bool
+	countSet       bool
+	genre          string
+	dryRun         bool
+	steamID        string
+	watch          time.Duration
+	statsOnly      bool
+	minGames       int
+	keychainSave   bool
+	achievements   bool
+	almostDone     float64
+	friendsPlay    string
+	newDays        int
+	compareSteamID string
+	sinceDate      string
+	since          time.Time
+	logLevelName   string
+	logLevel       slog.Level
+	open           bool
+	noBrowser      bool
+	noSave         bool
+	quiet          bool
+	args           []string
+	outputFile     string
+	category       string
+	developer      string
+	allProfiles    bool
+	playtimeGoal   float64
+	excludeDLC     bool
+	includeDLC     bool
+	watchNew       time.Duration
+	ignoreBeta     bool
+	markPlayed     int
+	unmarkPlayed   int
+	playtimeUnit   string
+	showImage      bool
+	apiBaseURL     string
+	steamAPI       SteamAPIConfig
+}
+
+// newFlagSet defines every wsipn flag on a new flag set. It is shared by parseFlags
+// and the cobra commands, which register the flags for parsing and completion.
+// Arguments:
+//   - opts: The options that the flag values are stored in.
+// Returns the flag set.
+func newFlagSet(opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("wsipn", flag.ContinueOnError)
+	fs.IntVar(&opts.threshold, "threshold", 120, "playtime in minutes below which a game counts as unplayed (0 = never played only); precedence: this flag, then $WSIPN_THRESHOLD, then 120")
+	fs.Float64Var(&opts.thresholdHours, "threshold-hours", 0, "playtime in hours below which a game counts as unplayed, e.g. 1.5 (overrides --threshold)")
+	fs.StringVar(&opts.exportJSON, "export-json", "", "write the full game list as JSON to this path (- for stdout)")
+	fs.StringVar(&opts.exportCSV, "export-csv", "", "write the full game list as CSV to this path (- for stdout)")
+	fs.StringVar(&opts.outputFile, "output-file", "", "write the selected game(s) as name<TAB>appid lines to this path (- for stdout)")
+	fs.BoolVar(&opts.recentlyPlayed, "recently-played", false, "show the top 10 games played in the last two weeks instead of a suggestion")
+	fs.DurationVar(&opts.cacheTTL, "cache-ttl", time.Hour, "reuse the cached game list if it is younger than this (0 disables the cache)")
+	fs.IntVar(&opts.topN, "top-n", 10, "number of most played games to list")
+	fs.StringVar(&opts.filter, "filter", "", "only consider games whose name contains this text (case-insensitive)")
+	fs.IntVar(&opts.count, "count", 1, "number of distinct random unplayed games to suggest")
+	fs.Float64Var(&opts.playtimeGoal, "playtime-goal", 0, "also show the game closest to (but not past) this many hours of playtime, e.g. 10")
+	fs.Float64Var(&opts.percentile, "percentile", 0, "also show the game at this playtime percentile, from 0.0 (least played) to 1.0 (most played), e.g. 0.9")
+	fs.BoolVar(&opts.interactive, "interactive", false, "browse the unplayed games in a terminal UI and print the selected one")
+	fs.BoolVar(&opts.weighted, "weighted", false, "favour recently played (as a proxy for recently added) games in the random selection")
+	fs.BoolVar(&opts.installedOnly, "installed-only", false, "only consider games installed in a local Steam library")
+	fs.StringVar(&opts.search, "search", "", "print the games whose names match this text, tolerating small typos")
+	fs.BoolVar(&opts.listAll, "list-all", false, "print the whole library as a numbered table, 20 rows per page (unpaged with --format json, csv or markdown)")
+	fs.BoolVar(&opts.diff, "diff", false, "show which games were played since the cached game list was saved, then refresh it")
+	fs.StringVar(&opts.configPath, "config-path", "", "directory used instead of the home directory for all wsipn files (config, profiles, caches, history, skip list)")
+	fs.Float64Var(&opts.sinceHours, "since-hours", 0, "only consider games last played within this many hours, e.g. 48")
+	fs.BoolVar(&opts.wishlist, "wishlist", false, "suggest from the public Steam wishlist instead of the owned games")
+	fs.BoolVar(&opts.verbose, "verbose", false, "print every HTTP request and its response status to stderr")
+	fs.BoolVar(&opts.verbose, "v", false, "shorthand for --verbose")
+	fs.BoolVar(&opts.shuffle, "shuffle", false, "print all unplayed games in random order, one per line (the first --count if given)")
+	fs.BoolVar(&opts.launch, "launch", false, "start the (first) selected game through Steam")
+	fs.BoolVar(&opts.callbackTLS, "callback-tls", false, "serve the local login callback over HTTPS with a self-signed certificate")
+	fs.BoolVar(&opts.quiet, "quiet", false, "print only the selected game(s), in the --format given; errors still go to stderr")
+	fs.BoolVar(&opts.noSave, "no-save", false, "never read or write the saved SteamID64; always log in through the browser")
+	fs.BoolVar(&opts.noBrowser, "no-browser", false, "print the Steam login URL instead of opening a browser (for headless machines)")
+	fs.BoolVar(&opts.open, "open", false, "open the Steam store page of the (first) selected game in the browser")
+	fs.StringVar(&opts.profile, "profile", "default", "name of the saved Steam profile to use")
+	fs.BoolVar(&opts.allProfiles, "all-profiles", false, "merge the libraries of all saved profiles and select from the combined list")
+	fs.BoolVar(&opts.listProfiles, "list-profiles", false, "list the saved profiles and exit")
+	fs.StringVar(&opts.exclude, "exclude", "", "comma-separated game names to never suggest (merged with ~/.wsipn_exclude)")
+	fs.IntVar(&opts.loginTimeout, "login-timeout", 2, "minutes to wait for the Steam login to complete in the browser")
+	fs.StringVar(&opts.sortBy, "sort-by", "name", "order of the listed games: name, playtime-asc, playtime-desc, appid, recent, last-played-asc or last-played-desc")
+	fs.StringVar(&opts.vanity, "vanity", "", "Steam custom profile name to resolve instead of logging in through the browser")
+	fs.StringVar(&opts.apiKey, "api-key", "", "Steam API key (overrides STEAM_API_KEY, .env and ~/.wsipn/config.json)")
+	fs.StringVar(&opts.format, "format", "text", "output format: text, json, csv or markdown")
+	fs.IntVar(&opts.minPlaytime, "min-playtime", 0, "only consider games played at least this many minutes, before any other filter")
+	fs.IntVar(&opts.maxPlaytime, "max-playtime", 0, "only consider games played less than this many minutes, before any other filter (0 = no limit)")
+	fs.Float64Var(&opts.minHours, "min-hours", 0, "only consider games played at least this many hours")
+	fs.Float64Var(&opts.maxHours, "max-hours", 0, "only consider games played less than this many hours (0 = no upper bound)")
+	fs.DurationVar(&opts.timeout, "timeout", 10*time.Second, "time limit for each Steam API request, including retries, e.g. 30s")
+	fs.Float64Var(&opts.rateLimit, "rate-limit", 1.0, "requests per second for per-game lookups such as store details and achievements")
+	fs.IntVar(&opts.maxRetries, "max-retries", 3, "how many times to retry Steam API requests that fail with 429 or 5xx")
+	fs.BoolVar(&opts.ignoreFree, "ignore-free", false, "leave well-known free-to-play games out of all selections")
+	fs.StringVar(&opts.webhookURL, "webhook-url", "", "Discord-compatible webhook to announce the selected game to")
+	fs.IntVar(&opts.historySize, "history-size", 30, "number of recent picks from ~/.wsipn_history to avoid suggesting again (0 disables)")
+	fs.Int64Var(&opts.seed, "seed", 0, "seed for the random selection; the result is only reproducible with an identical game list")
+	fs.BoolVar(&opts.histogram, "histogram", false, "show a histogram of the library by playtime instead of a suggestion")
+	fs.IntVar(&opts.inactiveDays, "inactive-days", 0, "also count the games not launched in more than this many days (0 = off)")
+	fs.BoolVar(&opts.bucketStats, "bucket-stats", false, "show the average playtime per playtime range instead of a suggestion")
+	fs.StringVar(&opts.genre, "genre", "", "only consider games of this store genre, e.g. RPG (fetches store details at --rate-limit games per second)")
+	fs.IntVar(&opts.markPlayed, "mark-played", 0, "never suggest the game with this app ID again (stored in ~/.wsipn_skip.json)")
+	fs.IntVar(&opts.unmarkPlayed, "unmark-played", 0, "allow the game with this app ID to be suggested again")
+	fs.StringVar(&opts.apiBaseURL, "api-base-url", defaultSteamAPIBaseURL, "base URL of the Steam Web API, e.g. a proxy or a local mock")
+	fs.BoolVar(&opts.showImage, "show-image", false, "show the header image of the (first) selected game: inline in iTerm2/WezTerm, as ASCII art elsewhere")
+	fs.StringVar(&opts.playtimeUnit, "playtime-unit", "hours", "unit used to display playtime: hours or minutes")
+	fs.BoolVar(&opts.ignoreBeta, "ignore-beta", false, "leave out beta, playtest and test app entries (names in ~/.wsipn_beta_allow are kept)")
+	fs.BoolVar(&opts.excludeDLC, "exclude-dlc", true, "leave out apps the Steam store lists as DLC; suggested games are checked with the store")
+	fs.BoolVar(&opts.includeDLC, "include-dlc", false, "keep DLC in all selections (same as --exclude-dlc=false)")
+	fs.StringVar(&opts.developer, "developer", "", "only consider games by this developer, e.g. Supergiant (fetches store details like --genre)")
+	fs.StringVar(&opts.category, "category", "", "only consider games in this store category, e.g. Multi-player or Co-op (fetches store details like --genre)")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "skip the Steam login and use --steam-id without saving it")
+	fs.StringVar(&opts.steamID, "steam-id", "", "SteamID64 to use with --dry-run")
+	fs.DurationVar(&opts.watchNew, "watch-new", 0, "check the library at this interval, e.g. 15m, and print a message for every new game (Ctrl-C to exit)")
+	fs.DurationVar(&opts.watch, "watch", 0, "re-fetch the library and print a new selection at this interval, e.g. 10m (Ctrl-C to exit)")
+	fs.BoolVar(&opts.statsOnly, "stats-only", false, "print library statistics without suggesting a game")
+	fs.IntVar(&opts.minGames, "min-games", 1, "exit with status 2 if the library has fewer games than this")
+	fs.BoolVar(&opts.keychainSave, "keychain-save", false, "store the API key (from --api-key or the environment) in the system keychain and exit")
+	fs.BoolVar(&opts.achievements, "achievements", false, "only consider games without any unlocked achievement (one request per game, at --rate-limit per second)")
+	fs.Float64Var(&opts.almostDone, "almost-done", 0, "list the games with at least this percentage of achievements unlocked, e.g. 80 (one request per game, at --rate-limit per second)")
+	fs.IntVar(&opts.newDays, "new", 0, "list the games probably added to the library in the last N days (estimated from app IDs)")
+	fs.StringVar(&opts.friendsPlay, "friends-play", "", "custom profile name of a friend; list your unplayed games they have played")
+	fs.StringVar(&opts.compareSteamID, "compare-steam-id", "", "SteamID64 of a public profile to compare libraries with")
+	fs.StringVar(&opts.sinceDate, "since", "", "only consider games last played after this date (YYYY-MM-DD); never played games are kept")
+	fs.StringVar(&opts.logLevelName, "log-level", "info", "minimum level of diagnostic messages: debug, info, warn or error")
+	return fs
+}
+
+// parseFlags parses and validates the command-line flags.
+// Arguments:
+//   - args: The command-line arguments, without the program name.
+// Returns the parsed options and an error if a flag is unknown or has an invalid value.
+func parseFlags(args []string) (options, error) {
+	var opts options
+	fs := newFlagSet(&opts)
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+	thresholdSet := false
+	thresholdHoursSet := false
+	logLevelSet := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "top-n":
+			opts.topNSet = true
+		case "seed":
+			opts.seedSet = true
+		case "count":
+			opts.countSet = true
+		case "percentile":
+			opts.percentileSet = true
+		case "log-level":
+			logLevelSet = true
+		case "threshold":
+			thresholdSet = true
+		case "threshold-hours":
+			thresholdHoursSet = true
+		}
+	})
+	if env := os.Getenv("WSIPN_THRESHOLD"); env != "" && !thresholdSet {
+		threshold, err := strconv.Atoi(env)
+		if err != nil {
+			return options{}, fmt.Errorf("WSIPN_THRESHOLD must be a whole number of minutes, got %q", env)
+		}
+		opts.threshold = threshold
+	}
+	if opts.threshold < 0 {
+		return options{}, fmt.Errorf("--threshold must be non-negative, got %d", opts.threshold)
+	}
+	if opts.thresholdHours < 0 {
+		return options{}, fmt.Errorf("--threshold-hours must be non-negative, got %g", opts.thresholdHours)
+	}
+	if !thresholdHoursSet {
+		opts.thresholdHours = float64(opts.threshold) / 60
+	}
+	if opts.percentile < 0 || opts.percentile > 1 || math.IsNaN(opts.percentile) {
+		return options{}, fmt.Errorf("--percentile must be between 0 and 1, got %g", opts.percentile)
+	}
+	if opts.diff && (opts.vanity != "" || opts.dryRun || opts.noSave) {
+		return options{}, errors.New("--diff compares against the saved profile's game cache and cannot be combined with --vanity, --dry-run or --no-save")
+	}
+	if opts.allProfiles && (opts.diff || opts.wishlist || opts.achievements || opts.almostDone > 0 ||
+		opts.vanity != "" || opts.dryRun || opts.noSave) {
+		return options{}, errors.New("--all-profiles cannot be combined with options for a single account: --diff, --wishlist, --achievements, --almost-done, --vanity, --dry-run or --no-save")
+	}
+	if opts.includeDLC {
+		opts.excludeDLC = false
+	}
+	if opts.playtimeGoal < 0 {
+		return options{}, fmt.Errorf("--playtime-goal must be non-negative, got %g", opts.playtimeGoal)
+	}
+	if opts.newDays < 0 {
+		return options{}, fmt.Errorf("--new must be non-negative, got %d", opts.newDays)
+	}
+	if opts.almostDone < 0 || opts.almostDone > 100 {
+		return options{}, fmt.Errorf("--almost-done must be between 0 and 100, got %g", opts.almostDone)
+	}
+	if opts.sinceHours < 0 {
+		return options{}, fmt.Errorf("--since-hours must be non-negative, got %g", opts.sinceHours)
+	}
+	if opts.inactiveDays < 0 {
+		return options{}, fmt.Errorf("--inactive-days must be non-negative, got %d", opts.inactiveDays)
+	}
+	if opts.topN < 1 {
+		return options{}, fmt.Errorf("--top-n must be at least 1, got %d", opts.topN)
+	}
+	if opts.minPlaytime < 0 || opts.maxPlaytime < 0 {
+		return options{}, errors.New("--min-playtime and --max-playtime must be non-negative")
+	}
+	if opts.maxPlaytime > 0 && opts.minPlaytime > opts.maxPlaytime {
+		return options{}, fmt.Errorf("--min-playtime (%d) must not exceed --max-playtime (%d)", opts.minPlaytime, opts.maxPlaytime)
+	}
+	if opts.minHours < 0 || opts.maxHours < 0 {
+		return options{}, errors.New("--min-hours and --max-hours must be non-negative")
+	}
+	if opts.maxHours > 0 && opts.minHours > opts.maxHours {
+		return options{}, fmt.Errorf("--min-hours (%g) must not exceed --max-hours (%g)", opts.minHours, opts.maxHours)
+	}
+	if opts.dryRun {
+		if opts.steamID == "" {
+			return options{}, errors.New("--dry-run requires --steam-id")
+		}
+		if err := validateSteamID64(opts.steamID); err != nil {
+			return options{}, fmt.Errorf("invalid --steam-id: %w", err)
+		}
+	} else if opts.steamID != "" {
+		return options{}, errors.New("--steam-id can only be used together with --dry-run")
+	}
+	if opts.compareSteamID != "" {
+		if err := validateSteamID64(opts.compareSteamID); err != nil {
+			return options{}, fmt.Errorf("invalid --compare-steam-id: %w", err)
+		}
+	}
+	if opts.sinceDate != "" {
+		since, err := time.ParseInLocation("2006-01-02", opts.sinceDate, time.Local)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid --since date %q, expected YYYY-MM-DD", opts.sinceDate)
+		}
+		opts.since = since
+	}
+	level, err := parseLogLevel(opts.logLevelName)
+	if err != nil {
+		return options{}, err
+	}
+	opts.logLevel = level
+	if opts.quiet && !logLevelSet {
+		// Warnings are not errors, so --quiet hides them unless a level is asked for.
+		opts.logLevel = slog.LevelError
+	}
+	if math.IsNaN(opts.rateLimit) || math.IsInf(opts.rateLimit, 0) || opts.rateLimit <= 0 || opts.rateLimit > maxRateLimit {
+		return options{}, fmt.Errorf("--rate-limit must be greater than 0 and at most %g requests per second, got %g", maxRateLimit, opts.rateLimit)
+	}
+	if opts.timeout < time.Second {
+		return options{}, fmt.Errorf("--timeout must be at least 1s, got %s", opts.timeout)
+	}
+	if opts.maxRetries < 0 {
+		return options{}, fmt.Errorf("--max-retries must be non-negative, got %d", opts.maxRetries)
+	}
+	if opts.historySize < 0 {
+		return options{}, fmt.Errorf("--history-size must be non-negative, got %d", opts.historySize)
+	}
+	if opts.minGames < 0 {
+		return options{}, fmt.Errorf("--min-games must be non-negative, got %d", opts.minGames)
+	}
+	if opts.count < 1 {
+		return options{}, fmt.Errorf("--count must be at least 1, got %d", opts.count)
+	}
+	if opts.loginTimeout < 1 {
+		return options{}, fmt.Errorf("--login-timeout must be at least 1 minute, got %d", opts.loginTimeout)
+	}
+	if _, err := newRenderer(opts.format); err != nil {
+		return options{}, err
+	}
+	if _, err := sortGames(nil, opts.sortBy); err != nil {
+		return options{}, err
+	}
+	if _, err := getSteamIDFilePath(opts.profile); err != nil {
+		return options{}, err
+	}
+	if opts.watch < 0 {
+		return options{}, fmt.Errorf("--watch must be non-negative, got %s", opts.watch)
+	}
+	if opts.watchNew < 0 {
+		return options{}, fmt.Errorf("--watch-new must be non-negative, got %s", opts.watchNew)
+	}
+	if opts.watchNew > 0 && opts.allProfiles {
+		return options{}, errors.New("--watch-new watches a single account and cannot be combined with --all-profiles")
+	}
+	if opts.cacheTTL < 0 {
+		return options{}, fmt.Errorf("--cache-ttl must be non-negative, got %s", opts.cacheTTL)
+	}
+	if opts.markPlayed < 0 || opts.unmarkPlayed < 0 {
+		return options{}, errors.New("--mark-played and --unmark-played need a positive app ID")
+	}
+	if opts.markPlayed != 0 && opts.unmarkPlayed != 0 {
+		return options{}, errors.New("--mark-played and --unmark-played cannot be combined")
+	}
+	if opts.playtimeUnit != "hours" && opts.playtimeUnit != "minutes" {
+		return options{}, fmt.Errorf("--playtime-unit must be hours or minutes, got %q", opts.playtimeUnit)
+	}
+	if opts.steamAPI, err = newSteamAPIConfig(opts.apiBaseURL); err != nil {
+		return options{}, err
+	}
+	opts.args = fs.Args()
+	return opts, nil
+}
+
+// performOpenIDLogin initiates the OpenID login process with Steam.
+// It gives up when ctx is done before a valid callback arrives, and returns
+// context.Canceled if the user presses Ctrl-C while waiting.
+// Arguments:
+//   - ctx: The context bounding the login; give it a deadline to limit how long the user has.
+//   - open: Opens the login URL in a browser, usually openBrowser.
+//   - noBrowser: Print the login URL instead of opening it in a browser.
+//   - callbackTLS: Serve the callback over HTTPS with a self-signed certificate.
+// Returns the SteamID64 as a string and an error if the login process fails or times out.
+func performOpenIDLogin(ctx context.Context, open BrowserOpener, noBrowser, callbackTLS bool) (string, error) {
+	port, err := getFreePort()
+	if err != nil {
+		return "", fmt.Errorf("could not get free port: %v", err)
+	}
+
+	scheme := "http"
+	var cert tls.Certificate
+	if callbackTLS {
+		scheme = "https"
+		if cert, err = generateSelfSignedCert(); err != nil {
+			return "", fmt.Errorf("could not create callback certificate: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "The login callback uses a self-signed certificate; your browser will warn about it.")
+		fmt.Fprintln(os.Stderr, "Only accept it if the browser shows this SHA-256 fingerprint:")
+		fmt.Fprintln(os.Stderr, certFingerprint(cert))
+	}
+	redirectURL := fmt.Sprintf("%s://localhost:%s/callback", scheme, port)
+	realmURL := fmt.Sprintf("%s://localhost:%s", scheme, port)
+	loginURL := fmt.Sprintf(
+		steamAPI.OpenIDURL+
+			"?openid.ns=%s"+
+			"&openid.mode=checkid_setup"+
+			"&openid.return_to=%s"+
+			"&openid.realm=%s"+
+			"&openid.identity=%s"+
+			"&openid.claimed_id=%s",
+		url.QueryEscape("http://specs.openid.net/auth/2.0"),
+		url.QueryEscape(redirectURL),
+		url.QueryEscape(realmURL),
+		url.QueryEscape("http://specs.openid.net/auth/2.0/identifier_select"),
+		url.QueryEscape("http://specs.openid.net/auth/2.0/identifier_select"),
+	)
+
+	if noBrowser {
+		fmt.Fprintln(os.Stderr, "Visit this URL in a browser to log in to Steam:")
+		fmt.Fprintln(os.Stderr, loginURL)
+		fmt.Fprintf(os.Stderr, "Steam redirects back to %s, so that port must reach this machine from the browser's machine,\n", redirectURL)
+		fmt.Fprintf(os.Stderr, "e.g. by forwarding it over SSH first: ssh -L %s:localhost:%s <this host>\n", port, port)
+	} else {
+		infof(os.Stderr, "Opening Steam login in your browser...\n")
+		if err := open(loginURL); err != nil {
+			fmt.Fprintln(os.Stderr, "Cannot open browser. Please visit this URL manually:")
+			fmt.Fprintln(os.Stderr, loginURL)
+		}
+	}
+
+	authChan := make(chan string, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		steamID64, err := steamIDFromAssertion(params, redirectURL)
+		if err != nil {
+			http.Error(w, "Invalid OpenID response: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		valid, err := verifyOpenIDAssertion(r.Context(), params)
+		if err != nil {
+			http.Error(w, "Could not verify login with Steam", http.StatusBadGateway)
+			return
+		}
+		if !valid {
+			http.Error(w, "Invalid OpenID assertion", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintln(w, "Authentication complete. You may close this window.")
+		select {
+		case authChan <- steamID64:
+		default:
+		}
+	})
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: mux,
+	}
+	if callbackTLS {
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Handle Ctrl-C ourselves so the server is shut down cleanly instead of the process dying mid-request.
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	interrupted := make(chan struct{})
+	go func() {
+		select {
+		case <-sigChan:
+			close(interrupted)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		var err error
+		if callbackTLS {
+			// The certificate comes from TLSConfig, so no files are needed.
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	defer func() {
+		// ctx is usually done by now; keep its values but give in-flight requests time to finish.
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	select {
+	case steamID64 := <-authChan:
+		return steamID64, nil
+	case err := <-serverErr:
+		return "", fmt.Errorf("callback server error: %w", err)
+	case <-ctx.Done():
+		select {
+		case <-interrupted:
+			return "", fmt.Errorf("login interrupted: %w", context.Canceled)
+		default:
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("no login received in time: %w", ctx.Err())
+		}
+		return "", fmt.Errorf("login cancelled: %w", ctx.Err())
+	}
+}
+
+// steamIDFromAssertion checks that the parameters of an OpenID callback are a positive assertion
+// for this login, and not e.g. a cancelled login or a response meant for another site.
+// The signature is not checked here; that is up to verifyOpenIDAssertion.
+// Arguments:
+//   - params: The query parameters received on the /callback request.
+//   - returnTo: The openid.return_to URL sent with the login request.
+// Returns the SteamID64 of the claimed_id and an error if any check fails.
+func steamIDFromAssertion(params url.Values, returnTo string) (string, error) {
+	if mode := params.Get("openid.mode"); mode != "id_res" {
+		return "", fmt.Errorf("unexpected openid.mode %q", mode)
+	}
+	if params.Get("openid.return_to") != returnTo {
+		return "", errors.New("openid.return_to does not match this login")
+	}
+	claimedID := params.Get("openid.claimed_id")
+	steamID64, ok := strings.CutPrefix(claimedID, steamOpenIDIdentityPrefix)
+	if !ok {
+		return "", fmt.Errorf("openid.claimed_id %q is not a Steam identity", claimedID)
+	}
+	if err := validateSteamID64(steamID64); err != nil {
+		return "", fmt.Errorf("invalid SteamID64 in openid.claimed_id: %w", err)
+	}
+	return steamID64, nil
+}
+
+// verifyOpenIDAssertion checks the OpenID assertion received on the callback with Steam.
+// It replays all callback parameters to steamAPI.OpenIDURL with openid.mode set to
+// check_authentication and looks for "is_valid:true" in the reply.
+// Arguments:
+//   - ctx: The context for the request, usually that of the callback request; it is bounded to 10 seconds.
+//   - params: The query parameters received on the /callback request.
+// Returns true if Steam confirms the assertion, and an error if the request fails.
+func verifyOpenIDAssertion(ctx context.Context, params url.Values) (bool, error) {
+	form := url.Values{}
+	for key, values := range params {
+		form[key] = values
+	}
+	form.Set("openid.mode", "check_authentication")
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", steamAPI.OpenIDURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("verifying assertion: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status from Steam OpenID: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("reading response: %w", err)
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.TrimSpace(line) == "is_valid:true" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// listGames fetches the list of games owned by the user through the given SteamClient.
+// The returned games are sorted alphabetically by name. The request is bounded by apiTimeout (--timeout).
+// Arguments:
+//   - ctx: The parent context for the request.
+//   - client: The SteamClient used to fetch the games.
+//   - steamID64: The user's SteamID64.
+// Returns the games and an error if the request fails or if the response is invalid.
+func listGames(ctx context.Context, client SteamClient, steamID64 string) ([]Game, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	games, err := client.GetOwnedGames(ctx, steamID64)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].Name < games[j].Name
+	})
+	return games, nil
+}
+
+// writeSelection writes the selected games one per line as "name<TAB>appid",
+// a format meant for scripts rather than people. The app ID is left out when unknown.
+// Arguments:
+//   - games: The selected games.
+//   - path: The file to write to, or "-" to write to standard output.
+// Returns an error if the file cannot be written.
+func writeSelection(games []Game, path string) error {
+	var buf bytes.Buffer
+	for _, game := range games {
+		if game.AppID == 0 {
+			fmt.Fprintln(&buf, game.Name)
+		} else {
+			fmt.Fprintf(&buf, "%s\t%d\n", game.Name, game.AppID)
+		}
+	}
+	if path == "-" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// listAllGamesPageSize is the number of rows --list-all prints before waiting for Enter.
+const listAllGamesPageSize = 20
+
+// listAllGames prints the whole library. The text format is a numbered table paged every
+// listAllGamesPageSize rows; the other formats print everything at once.
+// Arguments:
+//   - games: The games to list.
+//   - format: The --format value.
+//   - unit: The playtime unit, "hours" or "minutes".
+//   - in: The input to wait on for Enter between pages.
+//   - out: The output to write the list to.
+// Returns an error if the format is unknown or writing fails.
+func listAllGames(games []Game, format, unit string, in io.Reader, out io.Writer) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(games, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding games: %w", err)
+		}
+		_, err = out.Write(append(data, '\n'))
+		return err
+	case "csv":
+		return CSVRenderer{}.Render(out, Report{Unplayed: games})
+	case "markdown":
+		return exportMarkdown(games, unit, out)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	scanner := bufio.NewScanner(in)
+	ew := &errWriter{w: out}
+	ew.printf("%4s  %-50s %s\n", "#", "Game", "Played")
+	paging := true
+	for i, game := range games {
+		if paging && i > 0 && i%listAllGamesPageSize == 0 {
+			ew.printf("-- %d/%d, press Enter for more --", i, len(games))
+			// Without more input (e.g. piped stdin) print the rest in one go.
+			if !scanner.Scan() {
+				ew.printf("\n")
+				paging = false
+			}
+		}
+		ew.printf("%4d  %-50s %s\n", i+1, game.Name, formatPlaytime(game.PlaytimeForever, unit))
+	}
+	return ew.err
+}
+
+// exportGamesJSON writes the given games as indented JSON.
+// Arguments:
+//   - games: The games to export.
+//   - path: The file to write to, or "-" to write to standard output.
+// Returns an error if the games cannot be encoded or the file cannot be written.
+func exportGamesJSON(games []Game, path string) error {
+	data, err := json.MarshalIndent(games, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding games: %w", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// exportGamesCSV writes the given games as CSV with an appid,name,playtime_minutes,playtime_hours header.
+// Arguments:
+//   - games: The games to export.
+//   - path: The file to write to, or "-" to write to standard output.
+// Returns an error if the file cannot be created or written.
+func exportGamesCSV(games []Game, path string) error {
+	if path == "-" {
+		return writeGamesCSV(games, os.Stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeGamesCSV(games, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeGamesCSV writes the given games as RFC 4180 CSV.
+// Arguments:
+//   - games: The games to write.
+//   - w: The writer to write to.
+// Returns an error if writing fails.
+func writeGamesCSV(games []Game, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"appid", "name", "playtime_minutes", "playtime_hours"}); err != nil {
+		return err
+	}
+	for _, game := range games {
+		record := []string{
+			strconv.Itoa(game.AppID),
+			game.Name,
+			strconv.Itoa(game.PlaytimeForever),
+			strconv.FormatFloat(game.HoursPlayed(), 'f', 2, 64),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// compareLibraries splits two libraries into the games both own and the games only one of them owns,
+// matching games by app ID.
+// Arguments:
+//   - a: The first library.
+//   - b: The second library.
+// Returns the games in both libraries (as entries of a), the games only in a and the games only in b,
+// each in the order of its source library.
+func compareLibraries(a, b []Game) (both, onlyA, onlyB []Game) {
+	inA := make(map[int]bool, len(a))
+	for _, game := range a {
+		inA[game.AppID] = true
+	}
+	inB := make(map[int]bool, len(b))
+	for _, game := range b {
+		inB[game.AppID] = true
+	}
+	for _, game := range a {
+		if inB[game.AppID] {
+			both = append(both, game)
+		} else {
+			onlyA = append(onlyA, game)
+		}
+	}
+	for _, game := range b {
+		if !inA[game.AppID] {
+			onlyB = append(onlyB, game)
+		}
+	}
+	return both, onlyA, onlyB
+}
+
+// rankByRecency returns a copy of the games sorted by last-played time, most recent first.
+// Games that were never played (RtimeLastPlayed 0) end up last; ties keep their original relative order.
+// Arguments:
+//   - games: The games to rank.
+// Returns the ranked copy.
+func rankByRecency(games []Game) []Game {
+	ranked := make([]Game, len(games))
+	copy(ranked, games)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].RtimeLastPlayed > ranked[j].RtimeLastPlayed
+	})
+	return ranked
+}
+
+// sortGames returns a copy of the games sorted by the given key.
+// Supported keys are "name", "playtime-asc", "playtime-desc", "appid", "recent",
+// "last-played-asc" and "last-played-desc"; never played games (RtimeLastPlayed 0)
+// come first in "last-played-asc" and last in "last-played-desc".
+// Ties keep their original relative order.
+// Arguments:
+//   - games: The games to sort.
+//   - by: The sort key.
+// Returns the sorted copy and an error if the sort key is unknown.
+func sortGames(games []Game, by string) ([]Game, error) {
+	var less func(a, b Game) bool
+	switch by {
+	case "name":
+		less = func(a, b Game) bool { return a.Name < b.Name }
+	case "playtime-asc":
+		less = func(a, b Game) bool { return a.PlaytimeForever < b.PlaytimeForever }
+	case "playtime-desc":
+		less = func(a, b Game) bool { return a.PlaytimeForever > b.PlaytimeForever }
+	case "appid":
+		less = func(a, b Game) bool { return a.AppID < b.AppID }
+	case "recent":
+		return rankByRecency(games), nil
+	case "last-played-asc":
+		less = func(a, b Game) bool { return a.RtimeLastPlayed < b.RtimeLastPlayed }
+	case "last-played-desc":
+		less = func(a, b Game) bool { return a.RtimeLastPlayed > b.RtimeLastPlayed }
+	default:
+		return nil, fmt.Errorf("unknown sort key %q", by)
+	}
+	sorted := make([]Game, len(games))
+	copy(sorted, games)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted, nil
+}
+
+// filterGamesByName returns the games whose name contains substr, ignoring case.
+// Arguments:
+//   - games: The games to filter.
+//   - substr: The text to look for; an empty string disables the filter.
+// Returns the matching games, or the original slice if substr is empty.
+func filterGamesByName(games []Game, substr string) []Game {
+	if substr == "" {
+		return games
+	}
+	substr = strings.ToLower(substr)
+	filtered := make([]Game, 0)
+	for _, game := range games {
+		if strings.Contains(strings.ToLower(game.Name), substr) {
+			filtered = append(filtered, game)
+		}
+	}
+	return filtered
+}
+
+// excludeGames removes the games whose name matches one of the excluded names exactly, ignoring case.
+// Arguments:
+//   - games: The games to filter.
+//   - excluded: The game names to remove.
+// Returns the remaining games, or the original slice if nothing is excluded.
+func excludeGames(games []Game, excluded []string) []Game {
+	if len(excluded) == 0 {
+		return games
+	}
+	skip := make(map[string]bool, len(excluded))
+	for _, name := range excluded {
+		skip[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	kept := make([]Game, 0, len(games))
+	for _, game := range games {
+		if !skip[strings.ToLower(game.Name)] {
+			kept = append(kept, game)
+		}
+	}
+	return kept
+}
+
+// loadExcludeFile reads the game names listed in ~/.wsipn_exclude, one per line.
+// Blank lines are ignored.
+// Arguments:
+//   - None
+// Returns the excluded names and an error if the file cannot be read.
+func loadExcludeFile() ([]string, error) {
+	return loadNameListFile(".wsipn_exclude")
+}
+
+// loadNameListFile reads a file in the home directory that lists game names, one per line.
+// Blank lines are ignored.
+// Arguments:
+//   - name: The file name relative to the home directory.
+// Returns the names and an error if the file cannot be read.
+func loadNameListFile(name string) ([]string, error) {
+	home, err := storage.homeDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(home, name))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// splitList splits a comma-separated flag value into its trimmed, non-empty parts.
+// Arguments:
+//   - value: The comma-separated list.
+// Returns the parts in order.
+func splitList(value string) []string {
+	parts := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// vanityURLResponse represents the response of the Steam API when resolving a vanity URL.
+type vanityURLResponse struct {
+	Response struct {
+		SteamID string `json:"steamid"`
+		Success int    `json:"success"`
+		Message string `json:"message"`
+	} `json:"response"`
+}
+
+// resolveVanityURL converts a Steam custom profile name (e.g. "gaben") to a SteamID64
+// using the ISteamUser/ResolveVanityURL endpoint.
+// Arguments:
+//   - ctx: The context for the request.
+//   - apiKey: The Steam API key to authenticate the request.
+//   - vanityURL: The custom profile name to resolve.
+// Returns the SteamID64 and an error if the request fails or no profile matches.
+func resolveVanityURL(ctx context.Context, apiKey, vanityURL string) (string, error) {
+	apiURL := steamAPI.endpoint("ISteamUser/ResolveVanityURL/v1/", url.Values{
+		"key":       {apiKey},
+		"vanityurl": {vanityURL},
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("resolving vanity URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp vanityURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return "", fmt.Errorf("invalid response from Steam API: %w", err)
+	}
+	if apiResp.Response.Success != 1 || apiResp.Response.SteamID == "" {
+		return "", fmt.Errorf("no Steam profile found for %q: %s", vanityURL, apiResp.Response.Message)
+	}
+	return apiResp.Response.SteamID, nil
+}
+
+// UserSummary holds the public profile information of a Steam user.
+type UserSummary struct {
+	PersonaName string `json:"personaname"`
+	AvatarURL   string `json:"avatar"`
+}
+
+// playerSummariesResponse represents the response of ISteamUser/GetPlayerSummaries.
+type playerSummariesResponse struct {
+	Response struct {
+		Players []UserSummary `json:"players"`
+	} `json:"response"`
+}
+
+// getSteamUserSummary fetches the persona name and avatar of a Steam user.
+// Arguments:
+//   - ctx: The context for the request.
+//   - client: The HTTP client used for the request.
+//   - apiKey: The Steam API key.
+//   - steamID64: The user's SteamID64.
+// Returns the user summary and an error if the request fails or the user is unknown.
+func getSteamUserSummary(ctx context.Context, client *http.Client, apiKey, steamID64 string) (UserSummary, error) {
+	apiURL := steamAPI.endpoint("ISteamUser/GetPlayerSummaries/v2/", url.Values{
+		"key":      {apiKey},
+		"steamids": {steamID64},
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return UserSummary{}, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return UserSummary{}, fmt.Errorf("fetching player summary: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return UserSummary{}, fmt.Errorf("Steam API returned %s", resp.Status)
+	}
+
+	var apiResp playerSummariesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return UserSummary{}, fmt.Errorf("invalid response from Steam API: %w", err)
+	}
+	if len(apiResp.Response.Players) == 0 {
+		return UserSummary{}, fmt.Errorf("no Steam profile found for %s", steamID64)
+	}
+	return apiResp.Response.Players[0], nil
+}
+
+// getGamesWithNoRecentActivity returns the games not launched in more than the given number of days.
+// Games that were never played (RtimeLastPlayed 0) count as inactive.
+// Arguments:
+//   - games: The games to filter.
+//   - days: The number of days without a session.
+// Returns the inactive games in their original order.
+func getGamesWithNoRecentActivity(games []Game, days int) []Game {
+	cutoff := time.Now().AddDate(0, 0, -days).Unix()
+	inactive := make([]Game, 0)
+	for _, game := range games {
+		if game.RtimeLastPlayed < cutoff {
+			inactive = append(inactive, game)
+		}
+	}
+	return inactive
+}
+
+// filterByLastPlayedWithin returns the games last played within the past number of hours.
+// Games that were never played are left out.
+// Arguments:
+//   - games: The games to filter.
+//   - hours: The size of the window, fractions allowed.
+// Returns the matching games in their original order.
+func filterByLastPlayedWithin(games []Game, hours float64) []Game {
+	cutoff := time.Now().Add(-time.Duration(hours * float64(time.Hour))).Unix()
+	filtered := make([]Game, 0)
+	for _, game := range games {
+		if game.RtimeLastPlayed != 0 && game.RtimeLastPlayed >= cutoff {
+			filtered = append(filtered, game)
+		}
+	}
+	return filtered
+}
+
+// filterPlayedSince returns the games last played after the given time.
+// Games that were never played (RtimeLastPlayed 0) are always kept, which makes this
+// a rough approximation of recently acquired unplayed games.
+// Arguments:
+//   - games: The games to filter.
+//   - since: The cut-off time.
+// Returns the matching games in their original order.
+func filterPlayedSince(games []Game, since time.Time) []Game {
+	filtered := make([]Game, 0)
+	for _, game := range games {
+		if game.RtimeLastPlayed == 0 || game.RtimeLastPlayed > since.Unix() {
+			filtered = append(filtered, game)
+		}
+	}
+	return filtered
+}
+
+// getGamesInRange returns the games whose playtime lies in the band [minMinutes, maxMinutes).
+// Arguments:
+//   - games: The games to filter.
+//   - minMinutes: The inclusive lower playtime bound in minutes.
+//   - maxMinutes: The exclusive upper playtime bound in minutes; 0 means no upper bound.
+// Returns the games within the band and an error if minMinutes is greater than a non-zero maxMinutes.
+func getGamesInRange(games []Game, minMinutes, maxMinutes int) ([]Game, error) {
+	if maxMinutes > 0 && minMinutes > maxMinutes {
+		return nil, fmt.Errorf("minimum %d minutes is greater than maximum %d minutes", minMinutes, maxMinutes)
+	}
+	inRange := make([]Game, 0)
+	for _, game := range games {
+		if game.PlaytimeForever >= minMinutes && (maxMinutes == 0 || game.PlaytimeForever < maxMinutes) {
+			inRange = append(inRange, game)
+		}
+	}
+	return inRange, nil
+}
+
+// unplayedGames returns the games whose playtime is below the given threshold.
+// A threshold of 0 keeps only games with no playtime recorded at all.
+// Arguments:
+//   - games: The games to filter.
+//   - thresholdMinutes: The playtime in minutes below which a game counts as unplayed.
+// Returns the unplayed games in their original order.
+//
+// Deprecated: Use unplayedGamesWithThreshold, which takes the threshold in hours.
+func unplayedGames(games []Game, thresholdMinutes int) []Game {
+	unplayed := make([]Game, 0)
+	for _, game := range games {
+		if game.PlaytimeForever == 0 || game.PlaytimeForever < thresholdMinutes {
+			unplayed = append(unplayed, game)
+		}
+	}
+	return unplayed
+}
+
+// unplayedGamesWithThreshold returns the games whose playtime is below the given
+// threshold in hours, which may be fractional (e.g. 1.5).
+// A threshold of 0 keeps only games with no playtime recorded at all.
+// Arguments:
+//   - games: The games to filter.
+//   - thresholdHours: The playtime in hours below which a game counts as unplayed.
+// Returns the unplayed games in their original order.
+func unplayedGamesWithThreshold(games []Game, thresholdHours float64) []Game {
+	// Round rather than truncate so minute values converted to hours survive the trip back.
+	return unplayedGames(games, int(math.Round(thresholdHours*60)))
+}
+
+// recentlyPlayedGames returns the games played in the last two weeks,
+// sorted by two-week playtime in descending order.
+// Arguments:
+//   - games: The games to filter.
+//   - limit: The maximum number of games to return.
+// Returns at most limit games with a non-zero two-week playtime.
+func recentlyPlayedGames(games []Game, limit int) []Game {
+	recent := make([]Game, 0)
+	for _, game := range games {
+		if game.PlaytimeTwoWeeks > 0 {
+			recent = append(recent, game)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].PlaytimeTwoWeeks > recent[j].PlaytimeTwoWeeks
+	})
+	if len(recent) > limit {
+		recent = recent[:limit]
+	}
+	return recent
+}
+
+// topNGames returns the n games with the highest total playtime in descending order.
+// The input slice is not modified.
+// Arguments:
+//   - games: The games to rank.
+//   - n: The number of games to return, between 1 and len(games).
+// Returns the top n games and an error if n is out of range.
+func topNGames(games []Game, n int) ([]Game, error) {
+	if n < 1 || n > len(games) {
+		return nil, fmt.Errorf("n must be between 1 and %d, got %d", len(games), n)
+	}
+	ranked := make([]Game, len(games))
+	copy(ranked, games)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].PlaytimeForever > ranked[j].PlaytimeForever
+	})
+	return ranked[:n], nil
+}
+
+// getGameByAppID finds the game with the given app ID using a linear scan,
+// which is fast enough for typical library sizes.
+// Arguments:
+//   - games: The games to search.
+//   - appID: The Steam app ID to look for.
+// Returns the game and true, or a zero Game and false if no game has that app ID.
+func getGameByAppID(games []Game, appID int) (Game, bool) {
+	for _, game := range games {
+		if game.AppID == appID {
+			return game, true
+		}
+	}
+	return Game{}, false
+}
+
+// getMostPlayedGame returns the game with the highest total playtime.
+// When several games share the highest playtime the first one in the slice wins.
+// Arguments:
+//   - games: The games to search.
+// Returns the most played game and an error if the slice is empty.
+func getMostPlayedGame(games []Game) (Game, error) {
+	if len(games) == 0 {
+		return Game{}, errors.New("no games to choose from")
+	}
+	most := games[0]
+	for _, game := range games[1:] {
+		if game.PlaytimeForever > most.PlaytimeForever {
+			most = game
+		}
+	}
+	return most, nil
+}
+
+// getTopPercentileGame returns the game at the given percentile of the library ordered by playtime,
+// e.g. 0.9 for a game that only 10% of the library was played longer than.
+// Arguments:
+//   - games: The games to search.
+//   - percentile: The position between 0.0 (least played) and 1.0 (most played).
+// Returns the game and an error if the slice is empty or the percentile is out of range.
+func getTopPercentileGame(games []Game, percentile float64) (Game, error) {
+	if len(games) == 0 {
+		return Game{}, errors.New("no games to choose from")
+	}
+	if percentile < 0 || percentile > 1 || math.IsNaN(percentile) {
+		return Game{}, fmt.Errorf("percentile must be between 0 and 1, got %g", percentile)
+	}
+	sorted, _ := sortGames(games, "playtime-asc")
+	return sorted[int(math.Round(percentile*float64(len(sorted)-1)))], nil
+}
+
+// getGameClosestToGoal returns the game whose playtime is closest to the goal without exceeding it,
+// i.e. the game that needs the least additional playtime to reach the milestone.
+// Ties are broken by name.
+// Arguments:
+//   - games: The games to search.
+//   - goalMinutes: The playtime goal in minutes.
+// Returns the closest game and an error if every game is already past the goal.
+func getGameClosestToGoal(games []Game, goalMinutes int) (Game, error) {
+	var closest Game
+	found := false
+	for _, game := range games {
+		if game.PlaytimeForever > goalMinutes {
+			continue
+		}
+		if !found || game.PlaytimeForever > closest.PlaytimeForever ||
+			(game.PlaytimeForever == closest.PlaytimeForever && game.Name < closest.Name) {
+			closest = game
+			found = true
+		}
+	}
+	if !found {
+		return Game{}, fmt.Errorf("no game below the goal of %d minutes", goalMinutes)
+	}
+	return closest, nil
+}
+
+// getNeglectedGame returns the game whose last session lies furthest in the past.
+// Unlike getLeastPlayedGame it ignores games that were never started, surfacing
+// games that were tried once and then abandoned. Pass the games below the unplayed threshold.
+// Arguments:
+//   - games: The games to search, usually the unplayed games.
+// Returns the neglected game and an error if none of the games was ever played.
+func getNeglectedGame(games []Game) (Game, error) {
+	var neglected Game
+	found := false
+	for _, game := range games {
+		if game.RtimeLastPlayed == 0 {
+			continue
+		}
+		if !found || game.RtimeLastPlayed < neglected.RtimeLastPlayed {
+			neglected = game
+			found = true
+		}
+	}
+	if !found {
+		return Game{}, errors.New("no started games to choose from")
+	}
+	return neglected, nil
+}
+
+// getAllTimeFavourite returns the game with the most playtime per year owned, surfacing the game
+// the user keeps coming back to rather than the one with the most raw hours.
+// The time owned is approximated by DateAdded and counted as at least one year, so recently
+// added games are ranked by their total playtime. Games without a date are left out.
+// Arguments:
+//   - games: The games to search.
+// Returns the favourite game and an error if no game has both playtime and a date.
+func getAllTimeFavourite(games []Game) (Game, error) {
+	now := time.Now()
+	var favourite Game
+	best := -1.0
+	for _, game := range games {
+		if game.PlaytimeForever == 0 || game.DateAdded() == 0 {
+			continue
+		}
+		years := now.Sub(time.Unix(game.DateAdded(), 0)).Hours() / (24 * 365.25)
+		if years < 1 {
+			years = 1
+		}
+		if ratio := float64(game.PlaytimeForever) / years; ratio > best {
+			favourite = game
+			best = ratio
+		}
+	}
+	if best < 0 {
+		return Game{}, errors.New("no played games with a last played date")
+	}
+	return favourite, nil
+}
+
+// getLongestUnplayed returns the never played game that has probably been owned the longest.
+// The Steam API does not tell when a game was acquired, so this is a heuristic: app IDs are
+// assigned in increasing order as games are added to the Steam catalog, and an older game is
+// more likely to have been bought long ago. Among the games with exactly zero playtime, the one
+// with the smallest app ID wins.
+// Arguments:
+//   - games: The games to search.
+// Returns the game and an error if every game has been played.
+func getLongestUnplayed(games []Game) (Game, error) {
+	var longest Game
+	found := false
+	for _, game := range games {
+		if game.PlaytimeForever != 0 {
+			continue
+		}
+		if !found || game.AppID < longest.AppID {
+			longest = game
+			found = true
+		}
+	}
+	if !found {
+		return Game{}, errors.New("no never played games to choose from")
+	}
+	return longest, nil
+}
+
+// getStreakGame returns the game with the most playtime in the last two weeks,
+// breaking ties by total playtime in descending order.
+// Arguments:
+//   - games: The games to search.
+// Returns the streak game and an error if no game was played in the last two weeks.
+func getStreakGame(games []Game) (Game, error) {
+	var streak Game
+	found := false
+	for _, game := range games {
+		if game.PlaytimeTwoWeeks == 0 {
+			continue
+		}
+		if !found ||
+			game.PlaytimeTwoWeeks > streak.PlaytimeTwoWeeks ||
+			(game.PlaytimeTwoWeeks == streak.PlaytimeTwoWeeks && game.PlaytimeForever > streak.PlaytimeForever) {
+			streak = game
+			found = true
+		}
+	}
+	if !found {
+		return Game{}, errors.New("no games played in the last two weeks")
+	}
+	return streak, nil
+}
+
+// getCompletionistGame returns the started game that is closest to completing its next full hour
+// of playtime, as a "finish the hour" suggestion. Ties are broken alphabetically by name.
+// Arguments:
+//   - games: The games to search.
+// Returns the game and an error if no game has any playtime.
+func getCompletionistGame(games []Game) (Game, error) {
+	var best Game
+	bestRemaining := -1
+	for _, game := range games {
+		if game.PlaytimeForever == 0 {
+			continue
+		}
+		remaining := 60 - game.PlaytimeForever%60
+		if bestRemaining < 0 || remaining < bestRemaining || (remaining == bestRemaining && game.Name < best.Name) {
+			best = game
+			bestRemaining = remaining
+		}
+	}
+	if bestRemaining < 0 {
+		return Game{}, errors.New("no games with recorded playtime")
+	}
+	return best, nil
+}
+
+// getLeastPlayedGame returns the game with the lowest total playtime.
+// When several games share the lowest playtime the first one in the slice wins.
+// Arguments:
+//   - games: The games to search.
+// Returns the least played game and an error if the slice is empty.
+func getLeastPlayedGame(games []Game) (Game, error) {
+	if len(games) == 0 {
+		return Game{}, errors.New("no games to choose from")
+	}
+	least := games[0]
+	for _, game := range games[1:] {
+		if game.PlaytimeForever < least.PlaytimeForever {
+			least = game
+		}
+	}
+	return least, nil
+}
+
+// newSelectionRand creates the random source for a selection, seeded from --seed or the clock.
+// The seed is printed to stderr so a selection can be reproduced.
+// Arguments:
+//   - opts: The parsed command-line options.
+// Returns the random source.
+func newSelectionRand(opts options) *rand.Rand {
+	seed := time.Now().UnixNano()
+	if opts.seedSet {
+		seed = opts.seed
+	}
+	infof(os.Stderr, "Random seed: %d\n", seed)
+	return rand.New(rand.NewSource(seed))
+}
+
+// pickUnplayedGames picks opts.count games from pool, weighted by recency with --weighted.
+// With --exclude-dlc every picked game is checked with isDLC, and DLC is dropped from the pool
+// and the games picked again, so that DLC unknown to the store cache is not suggested either.
+// Arguments:
+//   - opts: The parsed command-line options.
+//   - pool: The unplayed games to pick from.
+//   - rng: The random source used for the picks.
+// Returns the picked games, none if the pool holds only DLC, and an error if opts.count is less than 1.
+func pickUnplayedGames(opts options, pool []Game, rng *rand.Rand) ([]Game, error) {
+	pick := getRandomUnplayedGames
+	if opts.weighted {
+		pick = getRandomGamesWeightedByRecency
+	}
+	for {
+		picked, err := pick(pool, opts.count, rng)
+		if err != nil || !opts.excludeDLC {
+			return picked, err
+		}
+		dlc := make(map[int]bool)
+		for _, game := range picked {
+			if isDLC(game.AppID) {
+				dlc[game.AppID] = true
+			}
+		}
+		if len(dlc) == 0 {
+			return picked, nil
+		}
+		remaining := make([]Game, 0, len(pool))
+		for _, game := range pool {
+			if !dlc[game.AppID] {
+				remaining = append(remaining, game)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil, nil
+		}
+		pool = remaining
+	}
+}
+
+// shuffleGames returns a copy of the games in random order using a full Fisher-Yates shuffle.
+// Arguments:
+//   - games: The games to shuffle.
+//   - rng: The random source used for the shuffle.
+// Returns the shuffled copy.
+func shuffleGames(games []Game, rng *rand.Rand) []Game {
+	shuffled := make([]Game, len(games))
+	copy(shuffled, games)
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	return shuffled
+}
+
+// getRandomUnplayedGames picks n distinct games from the unplayed list without replacement.
+// It runs a partial Fisher-Yates shuffle on a copy of the slice.
+// If fewer than n games are available, all of them are returned in shuffled order.
+// Arguments:
+//   - unplayed: The games to pick from.
+//   - n: The number of games to pick.
+//   - rng: The random source used for the shuffle.
+// Returns the picked games and an error if n is less than 1 or there are no games to pick from.
+func getRandomUnplayedGames(unplayed []Game, n int, rng *rand.Rand) ([]Game, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("n must be at least 1, got %d", n)
+	}
+	if len(unplayed) == 0 {
+		return nil, errors.New("no unplayed games to choose from")
+	}
+	if n > len(unplayed) {
+		n = len(unplayed)
+	}
+	shuffled := make([]Game, len(unplayed))
+	copy(shuffled, unplayed)
+	for i := 0; i < n; i++ {
+		j := i + rng.Intn(len(shuffled)-i)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	return shuffled[:n], nil
+}
+
+// getRandomGameWeightedByRecency picks one game at random, favouring recently added games.
+// Steam does not report when a game was bought, so RtimeLastPlayed is used as a proxy:
+// each game is weighted by 1/(1+days since it was last played). Games without a
+// timestamp get the weight of the oldest timestamped game, so a list without any
+// timestamps is sampled uniformly.
+// Arguments:
+//   - games: The games to pick from.
+//   - rng: The random source used for the pick.
+// Returns the picked game and an error if there are no games to pick from.
+func getRandomGameWeightedByRecency(games []Game, rng *rand.Rand) (Game, error) {
+	if len(games) == 0 {
+		return Game{}, errors.New("no games to choose from")
+	}
+	return games[pickWeightedIndex(recencyWeights(games), rng)], nil
+}
+
+// recencyWeights returns the weight of every game for getRandomGameWeightedByRecency.
+// Arguments:
+//   - games: The games to weigh.
+// Returns one weight per game, in the same order.
+func recencyWeights(games []Game) []float64 {
+	oldest := int64(0)
+	for _, game := range games {
+		if game.RtimeLastPlayed > 0 && (oldest == 0 || game.RtimeLastPlayed < oldest) {
+			oldest = game.RtimeLastPlayed
+		}
+	}
+
+	now := time.Now().Unix()
+	weights := make([]float64, len(games))
+	for i, game := range games {
+		last := game.RtimeLastPlayed
+		if last == 0 {
+			last = oldest
+		}
+		days := 0.0
+		if last > 0 && last < now {
+			days = float64(now-last) / (24 * 60 * 60)
+		}
+		weights[i] = 1 / (1 + days)
+	}
+	return weights
+}
+
+// pickWeightedIndex picks an index at random with probability proportional to its weight.
+// Arguments:
+//   - weights: The non-empty list of positive weights.
+//   - rng: The random source used for the pick.
+// Returns the picked index.
+func pickWeightedIndex(weights []float64, rng *rand.Rand) int {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	target := rng.Float64() * total
+	for i, weight := range weights {
+		target -= weight
+		if target < 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+// getRandomGamesWeightedByRecency picks n distinct games with getRandomGameWeightedByRecency,
+// without replacement.
+// Arguments:
+//   - games: The games to pick from.
+//   - n: The number of games to pick; all games are returned if fewer are available.
+//   - rng: The random source used for the picks.
+// Returns the picked games in pick order and an error if n is less than 1 or there are no games.
+func getRandomGamesWeightedByRecency(games []Game, n int, rng *rand.Rand) ([]Game, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("n must be at least 1, got %d", n)
+	}
+	pool := make([]Game, len(games))
+	copy(pool, games)
+	weights := recencyWeights(pool)
+	picked := make([]Game, 0, n)
+	for len(picked) < n && len(pool) > 0 {
+		// Remove the sampled index rather than matching by app ID, which a merged
+		// library may contain more than once.
+		idx := pickWeightedIndex(weights, rng)
+		picked = append(picked, pool[idx])
+		pool = append(pool[:idx], pool[idx+1:]...)
+		weights = append(weights[:idx], weights[idx+1:]...)
+	}
+	if len(picked) == 0 {
+		return nil, errors.New("no unplayed games to choose from")
+	}
+	return picked, nil
+}
+
+// describeThreshold returns a human readable description of the unplayed threshold
+// for use in the summary output.
+// Arguments:
+//   - thresholdMinutes: The playtime in minutes below which a game counts as unplayed.
+// Returns the description as a string.
+func describeThreshold(thresholdMinutes int) string {
+	if thresholdMinutes == 0 {
+		return "no playtime recorded"
+	}
+	return fmt.Sprintf("less than %d minutes played", thresholdMinutes)
+}
+
+// Note: The above code assumes that the .env file is properly set up with the STEAM_API_KEY.
@@ -0,0 +1,2679 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestGetMostPlayedGame(t *testing.T) {
+	tests := []struct {
+		name    string
+		games   []Game
+		want    Game
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			games:   nil,
+			wantErr: true,
+		},
+		{
+			name:  "single game",
+			games: []Game{{Name: "Portal", PlaytimeForever: 42}},
+			want:  Game{Name: "Portal", PlaytimeForever: 42},
+		},
+		{
+			name: "tie keeps first occurrence",
+			games: []Game{
+				{Name: "Celeste", PlaytimeForever: 10},
+				{Name: "Hades", PlaytimeForever: 300},
+				{Name: "Portal", PlaytimeForever: 300},
+			},
+			want: Game{Name: "Hades", PlaytimeForever: 300},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getMostPlayedGame(tt.games)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getMostPlayedGame() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getMostPlayedGame() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	games := []Game{
+		{Name: "Celeste", PlaytimeForever: 10},
+		{Name: "Hades", PlaytimeForever: 300, PlaytimeTwoWeeks: 45},
+	}
+
+	if err := saveCache(path, games); err != nil {
+		t.Fatalf("saveCache() error = %v", err)
+	}
+	got, savedAt, err := loadCache(path)
+	if err != nil {
+		t.Fatalf("loadCache() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, games) {
+		t.Errorf("loadCache() games = %+v, want %+v", got, games)
+	}
+	if time.Since(savedAt) > time.Minute {
+		t.Errorf("loadCache() savedAt = %v, want a recent timestamp", savedAt)
+	}
+}
+
+func TestLoadCacheMissingFile(t *testing.T) {
+	if _, _, err := loadCache(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loadCache() error = nil, want an error for a missing file")
+	}
+}
+
+func TestTopNGames(t *testing.T) {
+	games := []Game{
+		{Name: "Celeste", PlaytimeForever: 10},
+		{Name: "Hades", PlaytimeForever: 300},
+		{Name: "Portal", PlaytimeForever: 120},
+	}
+
+	tests := []struct {
+		name    string
+		n       int
+		want    []string
+		wantErr bool
+	}{
+		{name: "zero", n: 0, wantErr: true},
+		{name: "too many", n: 4, wantErr: true},
+		{name: "top one", n: 1, want: []string{"Hades"}},
+		{name: "all", n: 3, want: []string{"Hades", "Portal", "Celeste"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := topNGames(games, tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("topNGames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var names []string
+			for _, game := range got {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("topNGames() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+	if games[0].Name != "Celeste" {
+		t.Error("topNGames() modified the input slice")
+	}
+}
+
+func TestFilterGamesByName(t *testing.T) {
+	games := []Game{
+		{Name: "Dark Souls III"},
+		{Name: "DARK SOULS: REMASTERED"},
+		{Name: "Hades"},
+	}
+
+	tests := []struct {
+		name   string
+		substr string
+		want   []string
+	}{
+		{name: "empty filter", substr: "", want: []string{"Dark Souls III", "DARK SOULS: REMASTERED", "Hades"}},
+		{name: "case-insensitive", substr: "dark souls", want: []string{"Dark Souls III", "DARK SOULS: REMASTERED"}},
+		{name: "no match", substr: "Portal", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, game := range filterGamesByName(games, tt.substr) {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("filterGamesByName() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRandomUnplayedGamesDeterministic(t *testing.T) {
+	unplayed := []Game{
+		{Name: "Celeste"},
+		{Name: "Hades"},
+		{Name: "Outer Wilds"},
+		{Name: "Portal"},
+		{Name: "Tunic"},
+	}
+
+	first, err := getRandomUnplayedGames(unplayed, 3, rand.New(rand.NewSource(42)))
+	if err != nil {
+		t.Fatalf("getRandomUnplayedGames() error = %v", err)
+	}
+	second, err := getRandomUnplayedGames(unplayed, 3, rand.New(rand.NewSource(42)))
+	if err != nil {
+		t.Fatalf("getRandomUnplayedGames() error = %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed gave different picks: %v and %v", first, second)
+	}
+
+	seen := make(map[string]bool)
+	for _, game := range first {
+		if seen[game.Name] {
+			t.Errorf("getRandomUnplayedGames() picked %q twice", game.Name)
+		}
+		seen[game.Name] = true
+	}
+}
+
+func TestGetRandomUnplayedGamesShortList(t *testing.T) {
+	unplayed := []Game{{Name: "Celeste"}, {Name: "Hades"}}
+
+	got, err := getRandomUnplayedGames(unplayed, 5, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("getRandomUnplayedGames() error = %v", err)
+	}
+	if len(got) != len(unplayed) {
+		t.Errorf("getRandomUnplayedGames() returned %d games, want %d", len(got), len(unplayed))
+	}
+	if _, err := getRandomUnplayedGames(nil, 1, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("getRandomUnplayedGames() error = nil, want an error for an empty list")
+	}
+}
+
+func TestGetPlaytimeStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		minutes []int
+		want    PlaytimeStats
+		wantErr bool
+	}{
+		{name: "empty", wantErr: true},
+		{
+			name:    "single element",
+			minutes: []int{90},
+			want:    PlaytimeStats{Mean: 90, Median: 90, StdDev: 0, Total: 90},
+		},
+		{
+			name:    "odd count",
+			minutes: []int{30, 0, 60},
+			want:    PlaytimeStats{Mean: 30, Median: 30, StdDev: math.Sqrt(600), Total: 90},
+		},
+		{
+			name:    "even count",
+			minutes: []int{40, 10, 20, 30},
+			want:    PlaytimeStats{Mean: 25, Median: 25, StdDev: math.Sqrt(125), Total: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games := make([]Game, len(tt.minutes))
+			for i, m := range tt.minutes {
+				games[i] = Game{PlaytimeForever: m}
+			}
+			got, err := getPlaytimeStats(games)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getPlaytimeStats() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			const eps = 1e-9
+			if math.Abs(got.Mean-tt.want.Mean) > eps ||
+				math.Abs(got.Median-tt.want.Median) > eps ||
+				math.Abs(got.StdDev-tt.want.StdDev) > eps ||
+				got.Total != tt.want.Total {
+				t.Errorf("getPlaytimeStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludeGames(t *testing.T) {
+	games := []Game{
+		{Name: "Dark Souls III"},
+		{Name: "Hades"},
+		{Name: "Shovelware Deluxe"},
+	}
+
+	tests := []struct {
+		name     string
+		excluded []string
+		want     []string
+	}{
+		{name: "nothing excluded", excluded: nil, want: []string{"Dark Souls III", "Hades", "Shovelware Deluxe"}},
+		{name: "case-insensitive exact match", excluded: []string{"shovelware deluxe"}, want: []string{"Dark Souls III", "Hades"}},
+		{name: "substring does not match", excluded: []string{"Dark Souls"}, want: []string{"Dark Souls III", "Hades", "Shovelware Deluxe"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, game := range excludeGames(games, tt.excluded) {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("excludeGames() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortGames(t *testing.T) {
+	games := []Game{
+		{AppID: 400, Name: "Portal", PlaytimeForever: 120, RtimeLastPlayed: 1700000000},
+		{AppID: 1145360, Name: "Hades", PlaytimeForever: 300, RtimeLastPlayed: 1600000000},
+		{AppID: 504230, Name: "Celeste", PlaytimeForever: 10},
+	}
+
+	tests := []struct {
+		by      string
+		want    []string
+		wantErr bool
+	}{
+		{by: "name", want: []string{"Celeste", "Hades", "Portal"}},
+		{by: "playtime-asc", want: []string{"Celeste", "Portal", "Hades"}},
+		{by: "playtime-desc", want: []string{"Hades", "Portal", "Celeste"}},
+		{by: "appid", want: []string{"Portal", "Celeste", "Hades"}},
+		{by: "recent", want: []string{"Portal", "Hades", "Celeste"}},
+		{by: "last-played-asc", want: []string{"Celeste", "Hades", "Portal"}},
+		{by: "last-played-desc", want: []string{"Portal", "Hades", "Celeste"}},
+		{by: "rating", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			got, err := sortGames(games, tt.by)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sortGames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var names []string
+			for _, game := range got {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("sortGames() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortGamesLastPlayedZero(t *testing.T) {
+	games := []Game{
+		{Name: "Never A"},
+		{Name: "Recent", RtimeLastPlayed: 1700000000},
+		{Name: "Never B"},
+		{Name: "Old", RtimeLastPlayed: 1500000000},
+	}
+
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		{by: "last-played-asc", want: []string{"Never A", "Never B", "Old", "Recent"}},
+		{by: "last-played-desc", want: []string{"Recent", "Old", "Never A", "Never B"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			got, err := sortGames(games, tt.by)
+			if err != nil {
+				t.Fatalf("sortGames() error = %v", err)
+			}
+			var names []string
+			for _, game := range got {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("sortGames() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"steam_api_key": "ABC123"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.SteamAPIKey != "ABC123" {
+		t.Errorf("loadConfig() SteamAPIKey = %q, want %q", cfg.SteamAPIKey, "ABC123")
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`steam_api_key = "ABC123"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(invalid); err == nil {
+		t.Error("loadConfig() error = nil, want an error for invalid JSON")
+	}
+}
+
+// MockSteamClient is a SteamClient that returns canned data without network access.
+type MockSteamClient struct {
+	Games []Game
+	Err   error
+}
+
+func (m *MockSteamClient) GetOwnedGames(ctx context.Context, steamID64 string) ([]Game, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	games := make([]Game, len(m.Games))
+	copy(games, m.Games)
+	return games, nil
+}
+
+func TestListGamesWithMockClient(t *testing.T) {
+	client := &MockSteamClient{Games: []Game{
+		{AppID: 400, Name: "Portal"},
+		{AppID: 504230, Name: "Celeste"},
+		{AppID: 1145360, Name: "Hades"},
+	}}
+
+	games, err := listGames(context.Background(), client, "76561197960287930")
+	if err != nil {
+		t.Fatalf("listGames() error = %v", err)
+	}
+	var names []string
+	for _, game := range games {
+		names = append(names, game.Name)
+	}
+	if want := []string{"Celeste", "Hades", "Portal"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("listGames() = %v, want %v", names, want)
+	}
+
+	wantErr := errors.New("steam is down")
+	if _, err := listGames(context.Background(), &MockSteamClient{Err: wantErr}, "76561197960287930"); !errors.Is(err, wantErr) {
+		t.Errorf("listGames() error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestCompareLibraries(t *testing.T) {
+	portal := Game{AppID: 400, Name: "Portal"}
+	celeste := Game{AppID: 504230, Name: "Celeste"}
+	hades := Game{AppID: 1145360, Name: "Hades"}
+	tunic := Game{AppID: 553420, Name: "Tunic"}
+
+	tests := []struct {
+		name      string
+		a, b      []Game
+		wantBoth  []Game
+		wantOnlyA []Game
+		wantOnlyB []Game
+	}{
+		{
+			name:      "overlapping",
+			a:         []Game{portal, celeste, hades},
+			b:         []Game{hades, tunic, portal},
+			wantBoth:  []Game{portal, hades},
+			wantOnlyA: []Game{celeste},
+			wantOnlyB: []Game{tunic},
+		},
+		{
+			name:      "disjoint",
+			a:         []Game{portal, celeste},
+			b:         []Game{hades, tunic},
+			wantOnlyA: []Game{portal, celeste},
+			wantOnlyB: []Game{hades, tunic},
+		},
+		{
+			name:     "identical",
+			a:        []Game{portal, celeste},
+			b:        []Game{celeste, portal},
+			wantBoth: []Game{portal, celeste},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			both, onlyA, onlyB := compareLibraries(tt.a, tt.b)
+			if !reflect.DeepEqual(both, tt.wantBoth) {
+				t.Errorf("both = %v, want %v", both, tt.wantBoth)
+			}
+			if !reflect.DeepEqual(onlyA, tt.wantOnlyA) {
+				t.Errorf("onlyA = %v, want %v", onlyA, tt.wantOnlyA)
+			}
+			if !reflect.DeepEqual(onlyB, tt.wantOnlyB) {
+				t.Errorf("onlyB = %v, want %v", onlyB, tt.wantOnlyB)
+			}
+		})
+	}
+}
+
+func TestUnplayedGamesWithThreshold(t *testing.T) {
+	games := []Game{
+		{Name: "Never Played", PlaytimeForever: 0},
+		{Name: "Tried Once", PlaytimeForever: 45},
+		{Name: "Two Evenings", PlaytimeForever: 89},
+		{Name: "Finished", PlaytimeForever: 600},
+	}
+
+	tests := []struct {
+		name  string
+		hours float64
+		want  []string
+	}{
+		{name: "zero keeps never played only", hours: 0, want: []string{"Never Played"}},
+		{name: "fractional hours", hours: 1.5, want: []string{"Never Played", "Tried Once", "Two Evenings"}},
+		{name: "below first session", hours: 0.5, want: []string{"Never Played"}},
+		{name: "whole hours", hours: 2, want: []string{"Never Played", "Tried Once", "Two Evenings"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, game := range unplayedGamesWithThreshold(games, tt.hours) {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("unplayedGamesWithThreshold(%g) = %v, want %v", tt.hours, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankByRecency(t *testing.T) {
+	tests := []struct {
+		name  string
+		games []Game
+		want  []string
+	}{
+		{name: "empty", games: nil, want: nil},
+		{
+			name: "most recent first",
+			games: []Game{
+				{Name: "Old", RtimeLastPlayed: 1500000000},
+				{Name: "New", RtimeLastPlayed: 1700000000},
+				{Name: "Middle", RtimeLastPlayed: 1600000000},
+			},
+			want: []string{"New", "Middle", "Old"},
+		},
+		{
+			name: "never played last in original order",
+			games: []Game{
+				{Name: "Never A"},
+				{Name: "Played", RtimeLastPlayed: 1650000000},
+				{Name: "Never B"},
+			},
+			want: []string{"Played", "Never A", "Never B"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]Game(nil), tt.games...)
+			var names []string
+			for _, game := range rankByRecency(tt.games) {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("rankByRecency() = %v, want %v", names, tt.want)
+			}
+			if !reflect.DeepEqual(tt.games, original) {
+				t.Errorf("rankByRecency() modified its input")
+			}
+		})
+	}
+}
+
+func TestGetGameByAppID(t *testing.T) {
+	games := []Game{
+		{AppID: 400, Name: "Portal"},
+		{AppID: 1145360, Name: "Hades"},
+	}
+
+	tests := []struct {
+		name   string
+		appID  int
+		want   Game
+		wantOK bool
+	}{
+		{name: "found", appID: 1145360, want: Game{AppID: 1145360, Name: "Hades"}, wantOK: true},
+		{name: "missing", appID: 620, want: Game{}, wantOK: false},
+		{name: "zero app ID", appID: 0, want: Game{}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := getGameByAppID(games, tt.appID)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getGameByAppID(%d) = %+v, %v, want %+v, %v", tt.appID, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetUnplayedPercent(t *testing.T) {
+	tests := []struct {
+		name      string
+		games     []Game
+		threshold int
+		want      float64
+	}{
+		{name: "empty library", games: nil, threshold: 120, want: 0},
+		{
+			name:      "nothing unplayed",
+			games:     []Game{{Name: "Hades", PlaytimeForever: 3000}, {Name: "Portal", PlaytimeForever: 240}},
+			threshold: 120,
+			want:      0,
+		},
+		{
+			name:      "everything unplayed",
+			games:     []Game{{Name: "Celeste"}, {Name: "Inside", PlaytimeForever: 30}},
+			threshold: 120,
+			want:      100,
+		},
+		{
+			name:      "mixed",
+			games:     []Game{{Name: "Celeste"}, {Name: "Inside", PlaytimeForever: 30}, {Name: "Hades", PlaytimeForever: 3000}, {Name: "Portal", PlaytimeForever: 240}},
+			threshold: 120,
+			want:      50,
+		},
+		{
+			name:      "never played only",
+			games:     []Game{{Name: "Celeste"}, {Name: "Inside", PlaytimeForever: 30}, {Name: "Hades", PlaytimeForever: 3000}, {Name: "Portal", PlaytimeForever: 240}},
+			threshold: 0,
+			want:      25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getUnplayedPercent(tt.games, tt.threshold); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("getUnplayedPercent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipListRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "skip.json")
+
+	got, err := loadSkipList(path)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("loadSkipList() on missing file = %v, %v, want empty list and no error", got, err)
+	}
+
+	if err := saveSkipList(path, []int{400, 620}); err != nil {
+		t.Fatalf("saveSkipList() error = %v", err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	} else if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("skip file mode = %v, want 0600", perm)
+	}
+	got, err = loadSkipList(path)
+	if err != nil {
+		t.Fatalf("loadSkipList() error = %v", err)
+	}
+	if want := []int{400, 620}; !reflect.DeepEqual(got, want) {
+		t.Errorf("loadSkipList() = %v, want %v", got, want)
+	}
+
+	games := []Game{{AppID: 400, Name: "Portal"}, {AppID: 1145360, Name: "Hades"}, {AppID: 620, Name: "Portal 2"}}
+	if kept := removeSkipped(games, got); !reflect.DeepEqual(kept, []Game{{AppID: 1145360, Name: "Hades"}}) {
+		t.Errorf("removeSkipped() = %+v, want only Hades", kept)
+	}
+}
+
+func TestFormatPlaytime(t *testing.T) {
+	tests := []struct {
+		minutes int
+		unit    string
+		want    string
+	}{
+		{minutes: 0, unit: "hours", want: "0.0h"},
+		{minutes: 90, unit: "hours", want: "1.5h"},
+		{minutes: 61, unit: "hours", want: "1.0h"},
+		{minutes: 0, unit: "minutes", want: "0m"},
+		{minutes: 150, unit: "minutes", want: "150m"},
+	}
+
+	for _, tt := range tests {
+		if got := formatPlaytime(tt.minutes, tt.unit); got != tt.want {
+			t.Errorf("formatPlaytime(%d, %q) = %q, want %q", tt.minutes, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestGetNeglectedGame(t *testing.T) {
+	tests := []struct {
+		name    string
+		games   []Game
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", games: nil, wantErr: true},
+		{name: "never started only", games: []Game{{Name: "Celeste"}, {Name: "Inside"}}, wantErr: true},
+		{
+			name: "earliest last session wins",
+			games: []Game{
+				{Name: "Celeste"},
+				{Name: "Tried Last Month", PlaytimeForever: 20, RtimeLastPlayed: 1780000000},
+				{Name: "Tried Years Ago", PlaytimeForever: 45, RtimeLastPlayed: 1400000000},
+			},
+			want: "Tried Years Ago",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getNeglectedGame(tt.games)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getNeglectedGame() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("getNeglectedGame() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLongestUnplayed(t *testing.T) {
+	tests := []struct {
+		name    string
+		games   []Game
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", games: nil, wantErr: true},
+		{name: "all played", games: []Game{{AppID: 10, Name: "Counter-Strike", PlaytimeForever: 1}}, wantErr: true},
+		{
+			name: "smallest app ID among zero playtime wins",
+			games: []Game{
+				{AppID: 1145360, Name: "Hades"},
+				{AppID: 70, Name: "Half-Life", PlaytimeForever: 600},
+				{AppID: 400, Name: "Portal"},
+				{AppID: 504230, Name: "Celeste"},
+			},
+			want: "Portal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getLongestUnplayed(tt.games)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getLongestUnplayed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("getLongestUnplayed() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllTimeFavourite(t *testing.T) {
+	now := time.Now()
+	yearsAgo := func(years int) int64 { return now.AddDate(-years, 0, 0).Unix() }
+	tests := []struct {
+		name    string
+		games   []Game
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", games: nil, wantErr: true},
+		{name: "never played", games: []Game{{Name: "Celeste"}}, wantErr: true},
+		{
+			name: "playtime per year beats raw playtime",
+			games: []Game{
+				{Name: "Old Grind", PlaytimeForever: 6000, RtimeLastPlayed: yearsAgo(10)},
+				{Name: "Comfort Game", PlaytimeForever: 3000, RtimeLastPlayed: yearsAgo(2)},
+				{Name: "No Date", PlaytimeForever: 9000},
+			},
+			want: "Comfort Game",
+		},
+		{
+			name: "less than a year counts as one",
+			games: []Game{
+				{Name: "Yesterday", PlaytimeForever: 100, RtimeLastPlayed: now.AddDate(0, 0, -1).Unix()},
+				{Name: "Last Year", PlaytimeForever: 150, RtimeLastPlayed: yearsAgo(1)},
+			},
+			want: "Last Year",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getAllTimeFavourite(tt.games)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getAllTimeFavourite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("getAllTimeFavourite() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAveragePlaytimeByBucket(t *testing.T) {
+	buckets := []int{0, 60, 300, 600}
+	tests := []struct {
+		name  string
+		games []Game
+		want  map[string]float64
+	}{
+		{name: "empty", games: nil, want: map[string]float64{}},
+		{
+			name: "grouped by range",
+			games: []Game{
+				{Name: "A", PlaytimeForever: 0},
+				{Name: "B", PlaytimeForever: 30},
+				{Name: "C", PlaytimeForever: 60},
+				{Name: "D", PlaytimeForever: 240},
+				{Name: "E", PlaytimeForever: 900},
+				{Name: "F", PlaytimeForever: 1500},
+			},
+			want: map[string]float64{"0-60": 15, "60-300": 150, "600+": 1200},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getAveragePlaytimeByBucket(tt.games, buckets); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getAveragePlaytimeByBucket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGamesWithNoRecentActivity(t *testing.T) {
+	now := time.Now()
+	games := []Game{
+		{Name: "Never Played"},
+		{Name: "Yesterday", RtimeLastPlayed: now.AddDate(0, 0, -1).Unix()},
+		{Name: "Last Year", RtimeLastPlayed: now.AddDate(-1, 0, 0).Unix()},
+	}
+
+	tests := []struct {
+		days int
+		want []string
+	}{
+		{days: 0, want: []string{"Never Played", "Yesterday", "Last Year"}},
+		{days: 30, want: []string{"Never Played", "Last Year"}},
+		{days: 1000, want: []string{"Never Played"}},
+	}
+
+	for _, tt := range tests {
+		var names []string
+		for _, game := range getGamesWithNoRecentActivity(games, tt.days) {
+			names = append(names, game.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("getGamesWithNoRecentActivity(%d) = %v, want %v", tt.days, names, tt.want)
+		}
+	}
+}
+
+func TestShuffleGames(t *testing.T) {
+	games := []Game{{Name: "A"}, {Name: "B"}, {Name: "C"}, {Name: "D"}, {Name: "E"}}
+	original := append([]Game(nil), games...)
+
+	first := shuffleGames(games, rand.New(rand.NewSource(7)))
+	second := shuffleGames(games, rand.New(rand.NewSource(7)))
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("shuffleGames() with the same seed = %v and %v, want equal", first, second)
+	}
+	if !reflect.DeepEqual(games, original) {
+		t.Errorf("shuffleGames() modified its input")
+	}
+
+	seen := make(map[string]int)
+	for _, game := range first {
+		seen[game.Name]++
+	}
+	if len(first) != len(games) || len(seen) != len(games) {
+		t.Errorf("shuffleGames() = %v, want a permutation of %v", first, games)
+	}
+
+	if got := shuffleGames(nil, rand.New(rand.NewSource(7))); len(got) != 0 {
+		t.Errorf("shuffleGames(nil) = %v, want empty", got)
+	}
+}
+
+func TestGetTotalPlaytime(t *testing.T) {
+	tests := []struct {
+		name  string
+		games []Game
+		want  int
+	}{
+		{name: "no games", games: nil, want: 0},
+		{name: "single game", games: []Game{{PlaytimeForever: 90}}, want: 90},
+		{name: "several games", games: []Game{{PlaytimeForever: 90}, {PlaytimeForever: 0}, {PlaytimeForever: 74010}}, want: 74100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getTotalPlaytime(tt.games); got != tt.want {
+				t.Errorf("getTotalPlaytime() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		minutes int
+		want    string
+	}{
+		{minutes: 0, want: "0h 0m"},
+		{minutes: 59, want: "0h 59m"},
+		{minutes: 150, want: "2h 30m"},
+		{minutes: 1234*60 + 30, want: "1 234h 30m"},
+		{minutes: 1234567 * 60, want: "1 234 567h 0m"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDuration(tt.minutes); got != tt.want {
+			t.Errorf("formatDuration(%d) = %q, want %q", tt.minutes, got, tt.want)
+		}
+	}
+}
+
+func TestThresholdEnvFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		{name: "built-in default", env: "", args: nil, want: 120},
+		{name: "environment", env: "45", args: nil, want: 45},
+		{name: "flag wins over environment", env: "45", args: []string{"--threshold", "300"}, want: 300},
+		{name: "non-numeric environment", env: "two hours", args: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WSIPN_THRESHOLD", tt.env)
+			opts, err := parseFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && opts.threshold != tt.want {
+				t.Errorf("parseFlags() threshold = %d, want %d", opts.threshold, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	games := []Game{
+		{Name: "Celeste", PlaytimeForever: 0},
+		{Name: "Hades | Supergiant Edition", PlaytimeForever: 150},
+		{Name: "Portal", PlaytimeForever: 61},
+	}
+
+	var buf bytes.Buffer
+	if err := exportMarkdown(games, "hours", &buf); err != nil {
+		t.Fatalf("exportMarkdown() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(games)+2 {
+		t.Fatalf("exportMarkdown() wrote %d lines, want header, separator and %d rows:\n%s", len(lines), len(games), buf.String())
+	}
+	for _, line := range lines {
+		// An escaped pipe must not create an extra column.
+		cells := strings.Split(strings.ReplaceAll(line, `\|`, ""), "|")
+		if len(cells) != 5 {
+			t.Errorf("exportMarkdown() row %q has %d columns, want 3", line, len(cells)-2)
+		}
+	}
+	if want := `| 2 | Hades \| Supergiant Edition | 2.5h |`; lines[3] != want {
+		t.Errorf("exportMarkdown() row = %q, want %q", lines[3], want)
+	}
+
+	buf.Reset()
+	if err := exportMarkdown(games, "minutes", &buf); err != nil {
+		t.Fatalf("exportMarkdown() error = %v", err)
+	}
+	lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if want := `| 3 | Portal | 61m |`; lines[4] != want {
+		t.Errorf("exportMarkdown() with minutes row = %q, want %q", lines[4], want)
+	}
+}
+
+func TestFilterByLastPlayedWithin(t *testing.T) {
+	now := time.Now()
+	games := []Game{
+		{Name: "Never Played"},
+		{Name: "An Hour Ago", RtimeLastPlayed: now.Add(-time.Hour).Unix()},
+		{Name: "Yesterday", RtimeLastPlayed: now.Add(-30 * time.Hour).Unix()},
+		{Name: "Last Month", RtimeLastPlayed: now.AddDate(0, -1, 0).Unix()},
+	}
+
+	tests := []struct {
+		hours float64
+		want  []string
+	}{
+		{hours: 0.5, want: nil},
+		{hours: 1.5, want: []string{"An Hour Ago"}},
+		{hours: 48, want: []string{"An Hour Ago", "Yesterday"}},
+	}
+
+	for _, tt := range tests {
+		var names []string
+		for _, game := range filterByLastPlayedWithin(games, tt.hours) {
+			names = append(names, game.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("filterByLastPlayedWithin(%g) = %v, want %v", tt.hours, names, tt.want)
+		}
+	}
+}
+
+func TestGetTopPercentileGame(t *testing.T) {
+	games := []Game{
+		{Name: "E", PlaytimeForever: 5000},
+		{Name: "A", PlaytimeForever: 0},
+		{Name: "C", PlaytimeForever: 300},
+		{Name: "B", PlaytimeForever: 60},
+		{Name: "D", PlaytimeForever: 1200},
+	}
+
+	tests := []struct {
+		name       string
+		games      []Game
+		percentile float64
+		want       string
+		wantErr    bool
+	}{
+		{name: "lowest", games: games, percentile: 0, want: "A"},
+		{name: "median", games: games, percentile: 0.5, want: "C"},
+		{name: "ninetieth", games: games, percentile: 0.9, want: "E"},
+		{name: "highest", games: games, percentile: 1, want: "E"},
+		{name: "empty", games: nil, percentile: 0.5, wantErr: true},
+		{name: "below range", games: games, percentile: -0.1, wantErr: true},
+		{name: "above range", games: games, percentile: 1.5, wantErr: true},
+		{name: "NaN", games: games, percentile: math.NaN(), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getTopPercentileGame(tt.games, tt.percentile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getTopPercentileGame() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("getTopPercentileGame(%g) = %q, want %q", tt.percentile, got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlagsPercentile(t *testing.T) {
+	t.Setenv("WSIPN_THRESHOLD", "")
+	tests := []struct {
+		name    string
+		args    []string
+		want    float64
+		wantErr bool
+	}{
+		{name: "valid", args: []string{"--percentile", "0.9"}, want: 0.9},
+		{name: "below range", args: []string{"--percentile", "-0.1"}, wantErr: true},
+		{name: "above range", args: []string{"--percentile", "1.5"}, wantErr: true},
+		{name: "NaN", args: []string{"--percentile", "NaN"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && opts.percentile != tt.want {
+				t.Errorf("parseFlags() percentile = %g, want %g", opts.percentile, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffPlaytime(t *testing.T) {
+	before := []Game{
+		{AppID: 400, Name: "Portal", PlaytimeForever: 120},
+		{AppID: 1145360, Name: "Hades", PlaytimeForever: 300},
+		{AppID: 504230, Name: "Celeste", PlaytimeForever: 10},
+	}
+	after := []Game{
+		{AppID: 400, Name: "Portal", PlaytimeForever: 120},
+		{AppID: 1145360, Name: "Hades", PlaytimeForever: 420},
+		{AppID: 504230, Name: "Celeste", PlaytimeForever: 70},
+		{AppID: 620, Name: "Portal 2", PlaytimeForever: 15},
+		{AppID: 220, Name: "Half-Life 2"},
+	}
+
+	want := []PlaytimeDiff{
+		{AppID: 1145360, Name: "Hades", Before: 300, After: 420, Delta: 120},
+		{AppID: 504230, Name: "Celeste", Before: 10, After: 70, Delta: 60},
+		{AppID: 620, Name: "Portal 2", Before: 0, After: 15, Delta: 15},
+	}
+	if got := diffPlaytime(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffPlaytime() = %+v, want %+v", got, want)
+	}
+	if got := diffPlaytime(after, after); len(got) != 0 {
+		t.Errorf("diffPlaytime() of identical snapshots = %+v, want none", got)
+	}
+}
+
+func TestListAllGamesPaging(t *testing.T) {
+	games := make([]Game, 45)
+	for i := range games {
+		games[i] = Game{Name: fmt.Sprintf("Game %02d", i+1), PlaytimeForever: i * 60}
+	}
+
+	var out bytes.Buffer
+	if err := listAllGames(games, "text", "hours", strings.NewReader("\n\n"), &out); err != nil {
+		t.Fatalf("listAllGames() error = %v", err)
+	}
+	if got := strings.Count(out.String(), "press Enter for more"); got != 2 {
+		t.Errorf("listAllGames() printed %d page prompts, want 2:\n%s", got, out.String())
+	}
+	if !strings.Contains(out.String(), "  45  Game 45") {
+		t.Errorf("listAllGames() did not print the last game:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := listAllGames(games, "json", "hours", strings.NewReader(""), &out); err != nil {
+		t.Fatalf("listAllGames() json error = %v", err)
+	}
+	if strings.Contains(out.String(), "press Enter") {
+		t.Errorf("listAllGames() paged the JSON output")
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "hades", b: "", want: 5},
+		{a: "hades", b: "hades", want: 0},
+		{a: "hades", b: "hadse", want: 2},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "pokémon", b: "pokemon", want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSearchGames(t *testing.T) {
+	games := []Game{
+		{Name: "Celeste"},
+		{Name: "Hades"},
+		{Name: "Hades II"},
+		{Name: "Hadse"},
+		{Name: "Portal"},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "hades", want: []string{"Hades", "Hades II", "Hadse"}},
+		{query: "PORT", want: []string{"Portal"}},
+		{query: "celest", want: []string{"Celeste"}},
+		{query: "celsete", want: []string{"Celeste"}},
+		{query: "minecraft", want: nil},
+		{query: "  ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var names []string
+			for _, game := range searchGames(games, tt.query) {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("searchGames(%q) = %v, want %v", tt.query, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLibraryFolders(t *testing.T) {
+	dir := t.TempDir()
+	vdf := `"libraryfolders"
+{
+	// comment
+	"0"
+	{
+		"path"		"C:\\Program Files (x86)\\Steam"
+		"label"		""
+		"apps"
+		{
+			"228980"		"123456"
+			"620"		"9876543"
+		}
+	}
+	"1"
+	{
+		"path"		"D:\\Games"
+		"apps"
+		{
+			"1145360"		"1000"
+		}
+	}
+	"contentstatsid"		"123"
+}
+`
+	path := filepath.Join(dir, "libraryfolders.vdf")
+	if err := os.WriteFile(path, []byte(vdf), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	folders, err := parseLibraryFolders(path)
+	if err != nil {
+		t.Fatalf("parseLibraryFolders() error = %v", err)
+	}
+	if len(folders) != 2 {
+		t.Fatalf("parseLibraryFolders() returned %d folders, want 2", len(folders))
+	}
+	paths := map[string]bool{}
+	for _, folder := range folders {
+		paths[folder.Path] = true
+	}
+	if !paths[`C:\Program Files (x86)\Steam`] || !paths[`D:\Games`] {
+		t.Errorf("parseLibraryFolders() paths = %v", paths)
+	}
+
+	tests := []struct {
+		appID int
+		want  bool
+	}{
+		{appID: 620, want: true},
+		{appID: 1145360, want: true},
+		{appID: 440, want: false},
+	}
+	for _, tt := range tests {
+		if got := isGameInstalled(folders, tt.appID); got != tt.want {
+			t.Errorf("isGameInstalled(%d) = %v, want %v", tt.appID, got, tt.want)
+		}
+	}
+}
+
+func TestParseLibraryFoldersMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "libraryfolders.vdf")
+	if err := os.WriteFile(path, []byte(`"libraryfolders" { "0" { "path" "x"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseLibraryFolders(path); err == nil {
+		t.Error("parseLibraryFolders() error = nil, want error for unterminated block")
+	}
+}
+
+func TestIsGameInstalledAppManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "steamapps"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "steamapps", "appmanifest_70.acf"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	folders := []LibraryFolder{{Path: dir}}
+	if !isGameInstalled(folders, 70) {
+		t.Error("isGameInstalled(70) = false, want true from appmanifest")
+	}
+	if isGameInstalled(folders, 71) {
+		t.Error("isGameInstalled(71) = true, want false")
+	}
+}
+
+func TestExportGamesCSVRoundTrip(t *testing.T) {
+	games := []Game{
+		{AppID: 620, Name: "Portal 2", PlaytimeForever: 90},
+		{AppID: 1, Name: `Quotes "and", commas`, PlaytimeForever: 0},
+		{AppID: 2, Name: "Multi\nline", PlaytimeForever: 61},
+	}
+	path := filepath.Join(t.TempDir(), "games.csv")
+	if err := exportGamesCSV(games, path); err != nil {
+		t.Fatalf("exportGamesCSV() error = %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(records)-1 != len(games) {
+		t.Fatalf("got %d games, want %d", len(records)-1, len(games))
+	}
+	if want := []string{"appid", "name", "playtime_minutes", "playtime_hours"}; !reflect.DeepEqual(records[0], want) {
+		t.Errorf("header = %v, want %v", records[0], want)
+	}
+	if want := []string{"620", "Portal 2", "90", "1.50"}; !reflect.DeepEqual(records[1], want) {
+		t.Errorf("first row = %v, want %v", records[1], want)
+	}
+	if records[2][1] != games[1].Name || records[3][1] != games[2].Name {
+		t.Errorf("names did not round-trip: %q, %q", records[2][1], records[3][1])
+	}
+}
+
+func TestExportGamesCSVCreateError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "games.csv")
+	if err := exportGamesCSV(nil, path); err == nil {
+		t.Error("exportGamesCSV() error = nil, want error for missing directory")
+	}
+}
+
+func TestGetRandomGameWeightedByRecency(t *testing.T) {
+	now := time.Now().Unix()
+	day := int64(24 * 60 * 60)
+	games := []Game{
+		{AppID: 1, Name: "Recent", RtimeLastPlayed: now - day},
+		{AppID: 2, Name: "Old", RtimeLastPlayed: now - 1000*day},
+		{AppID: 3, Name: "Never"},
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		game, err := getRandomGameWeightedByRecency(games, rng)
+		if err != nil {
+			t.Fatalf("getRandomGameWeightedByRecency() error = %v", err)
+		}
+		counts[game.Name]++
+	}
+	if counts["Recent"] < 10*counts["Old"] || counts["Recent"] < 10*counts["Never"] {
+		t.Errorf("recent game not favoured: %v", counts)
+	}
+
+	if _, err := getRandomGameWeightedByRecency(nil, rng); err == nil {
+		t.Error("getRandomGameWeightedByRecency(nil) error = nil, want error")
+	}
+}
+
+func TestGetRandomGamesWeightedByRecencyDuplicateAppIDs(t *testing.T) {
+	now := time.Now().Unix()
+	games := []Game{
+		{AppID: 400, Name: "Portal (main)", RtimeLastPlayed: now - 1000*24*60*60},
+		{AppID: 400, Name: "Portal (family)", RtimeLastPlayed: now},
+	}
+
+	for seed := int64(0); seed < 50; seed++ {
+		picked, err := getRandomGamesWeightedByRecency(games, 2, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("getRandomGamesWeightedByRecency() error = %v", err)
+		}
+		if len(picked) != 2 || picked[0].Name == picked[1].Name {
+			t.Fatalf("seed %d: picked %v, want each entry once", seed, picked)
+		}
+	}
+}
+
+func TestGetRandomGamesWeightedByRecencyDistinct(t *testing.T) {
+	games := []Game{{AppID: 1}, {AppID: 2}, {AppID: 3}}
+	rng := rand.New(rand.NewSource(7))
+
+	picked, err := getRandomGamesWeightedByRecency(games, 5, rng)
+	if err != nil {
+		t.Fatalf("getRandomGamesWeightedByRecency() error = %v", err)
+	}
+	if len(picked) != 3 {
+		t.Fatalf("got %d games, want 3", len(picked))
+	}
+	seen := map[int]bool{}
+	for _, game := range picked {
+		if seen[game.AppID] {
+			t.Errorf("game %d picked twice", game.AppID)
+		}
+		seen[game.AppID] = true
+	}
+	if len(games) != 3 || games[0].AppID != 1 {
+		t.Error("input slice was modified")
+	}
+}
+
+func TestParseFlagsTimeout(t *testing.T) {
+	t.Setenv("WSIPN_THRESHOLD", "")
+	tests := []struct {
+		name    string
+		args    []string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", args: nil, want: 10 * time.Second},
+		{name: "custom", args: []string{"--timeout", "30s"}, want: 30 * time.Second},
+		{name: "too short", args: []string{"--timeout", "500ms"}, wantErr: true},
+		{name: "invalid", args: []string{"--timeout", "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && opts.timeout != tt.want {
+				t.Errorf("parseFlags() timeout = %s, want %s", opts.timeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestGameHoursPlayed(t *testing.T) {
+	tests := []struct {
+		minutes   int
+		wantHours float64
+		wantText  string
+	}{
+		{minutes: 0, wantHours: 0, wantText: "0.0h"},
+		{minutes: 90, wantHours: 1.5, wantText: "1.5h"},
+		{minutes: 125, wantHours: 125.0 / 60, wantText: "2.1h"},
+	}
+
+	for _, tt := range tests {
+		game := Game{PlaytimeForever: tt.minutes}
+		if got := game.HoursPlayed(); got != tt.wantHours {
+			t.Errorf("HoursPlayed() for %d minutes = %v, want %v", tt.minutes, got, tt.wantHours)
+		}
+		if got := game.HoursString(); got != tt.wantText {
+			t.Errorf("HoursString() for %d minutes = %q, want %q", tt.minutes, got, tt.wantText)
+		}
+	}
+}
+
+func TestParseFlagsPlaytimeWindow(t *testing.T) {
+	t.Setenv("WSIPN_THRESHOLD", "")
+	tests := []struct {
+		name    string
+		args    []string
+		wantMin int
+		wantMax int
+		wantErr bool
+	}{
+		{name: "neither", args: nil},
+		{name: "min only", args: []string{"--min-playtime", "30"}, wantMin: 30},
+		{name: "max only", args: []string{"--max-playtime", "600"}, wantMax: 600},
+		{name: "max zero is no limit", args: []string{"--min-playtime", "30", "--max-playtime", "0"}, wantMin: 30},
+		{name: "both", args: []string{"--min-playtime", "30", "--max-playtime", "600"}, wantMin: 30, wantMax: 600},
+		{name: "min above max", args: []string{"--min-playtime", "700", "--max-playtime", "600"}, wantErr: true},
+		{name: "negative", args: []string{"--min-playtime", "-1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.minPlaytime != tt.wantMin || opts.maxPlaytime != tt.wantMax {
+				t.Errorf("parseFlags() window = [%d, %d), want [%d, %d)",
+					opts.minPlaytime, opts.maxPlaytime, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestGamePickerSelect(t *testing.T) {
+	games := []Game{{AppID: 400, Name: "Portal"}, {AppID: 620, Name: "Portal 2"}}
+
+	model, cmd := newGamePicker(games, "hours").Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Error("Update(enter) returned no command, want tea.Quit")
+	}
+	selected := model.(gamePicker).selected
+	if selected == nil || selected.Name != "Portal" {
+		t.Errorf("Update(enter) selected = %v, want Portal", selected)
+	}
+
+	model, cmd = newGamePicker(games, "hours").Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Error("Update(esc) returned no command, want tea.Quit")
+	}
+	if selected := model.(gamePicker).selected; selected != nil {
+		t.Errorf("Update(esc) selected = %v, want nil", selected)
+	}
+}
+
+func TestNoSave(t *testing.T) {
+	t.Setenv("WSIPN_THRESHOLD", "")
+	if _, err := parseFlags([]string{"--no-save", "--diff"}); err == nil {
+		t.Error("parseFlags(--no-save --diff) error = nil, want error")
+	}
+	opts, err := parseFlags([]string{"--no-save", "--dry-run", "--steam-id", "76561197960287930"})
+	if err != nil {
+		t.Fatalf("parseFlags(--no-save --dry-run) error = %v", err)
+	}
+	if !opts.noSave {
+		t.Error("parseFlags() noSave = false, want true")
+	}
+	if ttl := effectiveCacheTTL(options{noSave: true, cacheTTL: time.Hour}); ttl != 0 {
+		t.Errorf("effectiveCacheTTL() with --no-save = %s, want 0", ttl)
+	}
+	if err := runLogin(context.Background(), options{noSave: true}); err == nil {
+		t.Error("runLogin() with --no-save error = nil, want error")
+	}
+}
+
+func TestGetAlmostCompletedGames(t *testing.T) {
+	games := []GameWithAchievements{
+		{Game: Game{Name: "Celeste"}, AchievementsTotal: 30, AchievementsUnlocked: 27},
+		{Game: Game{Name: "Hades"}, AchievementsTotal: 49, AchievementsUnlocked: 10},
+		{Game: Game{Name: "Portal"}, AchievementsTotal: 15, AchievementsUnlocked: 15},
+		{Game: Game{Name: "No Achievements"}},
+		{Game: Game{Name: "Inside"}, AchievementsTotal: 10, AchievementsUnlocked: 8},
+	}
+
+	tests := []struct {
+		threshold float64
+		want      []string
+	}{
+		{threshold: 0.9, want: []string{"Celeste", "Portal"}},
+		{threshold: 0.8, want: []string{"Celeste", "Portal", "Inside"}},
+		{threshold: 1, want: []string{"Portal"}},
+	}
+
+	for _, tt := range tests {
+		var names []string
+		for _, game := range getAlmostCompletedGames(games, tt.threshold) {
+			names = append(names, game.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("getAlmostCompletedGames(%g) = %v, want %v", tt.threshold, names, tt.want)
+		}
+	}
+}
+
+func TestGetFriendRecommendations(t *testing.T) {
+	mine := []Game{
+		{AppID: 1, Name: "Celeste"},
+		{AppID: 2, Name: "Hades", PlaytimeForever: 3000},
+		{AppID: 3, Name: "Inside", PlaytimeForever: 30},
+		{AppID: 4, Name: "Portal"},
+		{AppID: 5, Name: "Only Mine"},
+	}
+	friends := []Game{
+		{AppID: 1, Name: "Celeste", PlaytimeForever: 600},
+		{AppID: 2, Name: "Hades", PlaytimeForever: 900},
+		{AppID: 3, Name: "Inside", PlaytimeForever: 1200},
+		{AppID: 4, Name: "Portal"},
+		{AppID: 6, Name: "Only Theirs", PlaytimeForever: 50},
+	}
+
+	got := getFriendRecommendations(mine, friends, 2)
+	want := []FriendRecommendation{
+		{Game: mine[2], FriendPlaytime: 1200},
+		{Game: mine[0], FriendPlaytime: 600},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getFriendRecommendations() = %+v, want %+v", got, want)
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	defer func(old bool) { quiet = old }(quiet)
+
+	var buf bytes.Buffer
+	quiet = false
+	infof(&buf, "Random seed: %d\n", 42)
+	if buf.String() != "Random seed: 42\n" {
+		t.Errorf("infof() wrote %q, want the message", buf.String())
+	}
+	buf.Reset()
+	quiet = true
+	infof(&buf, "Random seed: %d\n", 42)
+	if buf.Len() != 0 {
+		t.Errorf("infof() with --quiet wrote %q, want nothing", buf.String())
+	}
+
+	t.Setenv("WSIPN_THRESHOLD", "")
+	opts, err := parseFlags([]string{"--quiet"})
+	if err != nil {
+		t.Fatalf("parseFlags(--quiet) error = %v", err)
+	}
+	if opts.logLevel != slog.LevelError {
+		t.Errorf("parseFlags(--quiet) log level = %v, want %v", opts.logLevel, slog.LevelError)
+	}
+	opts, err = parseFlags([]string{"--quiet", "--log-level", "warn"})
+	if err != nil {
+		t.Fatalf("parseFlags(--quiet --log-level warn) error = %v", err)
+	}
+	if opts.logLevel != slog.LevelWarn {
+		t.Errorf("parseFlags(--quiet --log-level warn) log level = %v, want %v", opts.logLevel, slog.LevelWarn)
+	}
+}
+
+func TestEstimateAddedDate(t *testing.T) {
+	if got := estimateAddedDate(400).Year(); got != 2007 {
+		t.Errorf("estimateAddedDate(400) year = %d, want 2007", got)
+	}
+	between := estimateAddedDate(1000000)
+	if !between.After(estimateAddedDate(504230)) || !between.Before(estimateAddedDate(1145360)) {
+		t.Errorf("estimateAddedDate(1000000) = %s, want between the Celeste and Hades milestones", between)
+	}
+	if got := estimateAddedDate(math.MaxInt32); got.After(time.Now()) {
+		t.Errorf("estimateAddedDate(MaxInt32) = %s, want no later than now", got)
+	}
+	previous := time.Time{}
+	for appID := 0; appID < 4000000; appID += 50000 {
+		date := estimateAddedDate(appID)
+		if date.Before(previous) {
+			t.Fatalf("estimateAddedDate(%d) = %s, before the estimate for a smaller app ID", appID, date)
+		}
+		previous = date
+	}
+}
+
+func TestListRecentlyAdded(t *testing.T) {
+	games := []Game{
+		{AppID: 400, Name: "Portal"},
+		{AppID: math.MaxInt32 - 1, Name: "Brand New"},
+		{AppID: math.MaxInt32, Name: "Newer"},
+	}
+	var names []string
+	for _, game := range listRecentlyAdded(games, 30) {
+		names = append(names, game.Name)
+	}
+	if want := []string{"Newer", "Brand New"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("listRecentlyAdded() = %v, want %v", names, want)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(20) // one call every 50ms
+	defer limiter.Stop()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	// The first call is immediate, the other three wait one interval each.
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("4 calls took %s, want at least 150ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRateLimiterBlocksUntilContextDone(t *testing.T) {
+	limiter := NewRateLimiter(0.1) // one call every 10s
+	defer limiter.Stop()
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestNewRateLimiterClampsInterval(t *testing.T) {
+	for _, rps := range []float64{0, -1, math.NaN(), math.Inf(1), math.Inf(-1), 2e9, 1e-300} {
+		limiter := NewRateLimiter(rps) // must not panic
+		limiter.Stop()
+	}
+}
+
+func TestParseFlagsRateLimit(t *testing.T) {
+	t.Setenv("WSIPN_THRESHOLD", "")
+	tests := []struct {
+		name    string
+		args    []string
+		want    float64
+		wantErr bool
+	}{
+		{name: "default", args: nil, want: 1},
+		{name: "custom", args: []string{"--rate-limit", "2.5"}, want: 2.5},
+		{name: "maximum", args: []string{"--rate-limit", "10"}, want: 10},
+		{name: "above ceiling", args: []string{"--rate-limit", "10.5"}, wantErr: true},
+		{name: "zero", args: []string{"--rate-limit", "0"}, wantErr: true},
+		{name: "negative", args: []string{"--rate-limit", "-1"}, wantErr: true},
+		{name: "NaN", args: []string{"--rate-limit", "NaN"}, wantErr: true},
+		{name: "infinite", args: []string{"--rate-limit", "inf"}, wantErr: true},
+		{name: "too high", args: []string{"--rate-limit", "2e9"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && opts.rateLimit != tt.want {
+				t.Errorf("parseFlags() rateLimit = %g, want %g", opts.rateLimit, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGamesByDeveloper(t *testing.T) {
+	games := []Game{
+		{AppID: 1145360, Name: "Hades"},
+		{AppID: 237930, Name: "Transistor"},
+		{AppID: 504230, Name: "Celeste"},
+		{AppID: 400, Name: "Portal"},
+	}
+	details := map[int]GameDetails{
+		1145360: {AppID: 1145360, Developer: "Supergiant Games"},
+		237930:  {AppID: 237930, Developer: "Supergiant Games"},
+		504230:  {AppID: 504230, Developer: "Maddy Makes Games Inc., Extremely OK Games, Ltd."},
+	}
+
+	tests := []struct {
+		developer string
+		want      []string
+	}{
+		{developer: "supergiant", want: []string{"Hades", "Transistor"}},
+		{developer: "EXTREMELY OK", want: []string{"Celeste"}},
+		{developer: "Valve", want: nil},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, game := range getGamesByDeveloper(games, details, tt.developer) {
+			names = append(names, game.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("getGamesByDeveloper(%q) = %v, want %v", tt.developer, names, tt.want)
+		}
+	}
+}
+
+func TestLoadStoreCacheSkipsOldVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	now := time.Now().Format(time.RFC3339)
+	data := fmt.Sprintf(`{"1": {"fetched_at": %q, "details": {"appid": 1}},
+		"2": {"version": %d, "fetched_at": %q, "details": {"appid": 2, "developer": "Valve"}}}`,
+		now, storeCacheVersion, now)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	details, err := loadStoreCache(path)
+	if err != nil {
+		t.Fatalf("loadStoreCache() error = %v", err)
+	}
+	if _, ok := details[1]; ok {
+		t.Error("loadStoreCache() kept an entry without a version")
+	}
+	if details[2].Developer != "Valve" {
+		t.Errorf("loadStoreCache() entry 2 = %+v, want developer Valve", details[2])
+	}
+}
+
+func TestMergeLibraries(t *testing.T) {
+	alice := []Game{
+		{AppID: 400, Name: "Portal", PlaytimeForever: 120},
+		{AppID: 1145360, Name: "Hades", PlaytimeForever: 0},
+	}
+	bob := []Game{
+		{AppID: 1145360, Name: "Hades", PlaytimeForever: 3000},
+		{AppID: 504230, Name: "Celeste", PlaytimeForever: 10},
+		{AppID: 400, Name: "Portal", PlaytimeForever: 60},
+	}
+
+	got := mergeLibraries(alice, bob)
+	want := []Game{
+		{AppID: 504230, Name: "Celeste", PlaytimeForever: 10},
+		{AppID: 1145360, Name: "Hades", PlaytimeForever: 3000},
+		{AppID: 400, Name: "Portal", PlaytimeForever: 120},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeLibraries() = %+v, want %+v", got, want)
+	}
+	if got := mergeLibraries(); len(got) != 0 {
+		t.Errorf("mergeLibraries() with no libraries = %v, want empty", got)
+	}
+}
+
+func TestListAllProfilesGames(t *testing.T) {
+	defer func(old StorageConfig) { storage = old }(storage)
+	storage = StorageConfig{BaseDir: t.TempDir()}
+
+	client := &MockSteamClient{Games: []Game{{AppID: 400, Name: "Portal"}}}
+	if _, err := listAllProfilesGames(context.Background(), client, 0); err == nil {
+		t.Error("listAllProfilesGames() without profiles error = nil, want error")
+	}
+
+	for _, profile := range []string{"alice", "bob"} {
+		if err := saveSteamID64(profile, "76561197960287930"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	games, err := listAllProfilesGames(context.Background(), client, 0)
+	if err != nil {
+		t.Fatalf("listAllProfilesGames() error = %v", err)
+	}
+	if len(games) != 1 || games[0].Name != "Portal" {
+		t.Errorf("listAllProfilesGames() = %+v, want only Portal", games)
+	}
+}
+
+func TestBackupRestoreProfiles(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"config.json":        `{"steam_api_key": "secret"}`,
+		"profiles/default":   "76561197960287930",
+		"profiles/secondary": "76561197960287931",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive := filepath.Join(t.TempDir(), "backup.zip")
+	if err := backupProfiles(src, archive); err != nil {
+		t.Fatalf("backupProfiles() error = %v", err)
+	}
+	dst := filepath.Join(t.TempDir(), ".wsipn")
+	if err := restoreProfiles(archive, dst); err != nil {
+		t.Fatalf("restoreProfiles() error = %v", err)
+	}
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("restored %s: %v", name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("restored %s = %q, want %q", name, got, want)
+		}
+	}
+
+	if err := backupProfiles(filepath.Join(src, "missing"), archive); err == nil {
+		t.Error("backupProfiles() of a missing directory error = nil, want error")
+	}
+}
+
+func TestRestoreProfilesRejectsEscapingPaths(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "evil.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("../outside")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("x"))
+	zw.Close()
+	f.Close()
+
+	dst := filepath.Join(t.TempDir(), ".wsipn")
+	if err := restoreProfiles(archive, dst); err == nil {
+		t.Error("restoreProfiles() error = nil, want error for ../outside")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dst), "outside")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("restoreProfiles() wrote outside the config directory")
+	}
+}
+
+func TestGetGameClosestToGoal(t *testing.T) {
+	tests := []struct {
+		name    string
+		games   []Game
+		goal    int
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", games: nil, goal: 600, wantErr: true},
+		{name: "all past the goal", games: []Game{{Name: "Hades", PlaytimeForever: 700}}, goal: 600, wantErr: true},
+		{
+			name: "closest below wins over closer above",
+			games: []Game{
+				{Name: "Hades", PlaytimeForever: 601},
+				{Name: "Celeste", PlaytimeForever: 540},
+				{Name: "Portal", PlaytimeForever: 100},
+			},
+			goal: 600,
+			want: "Celeste",
+		},
+		{name: "exactly at the goal", games: []Game{{Name: "Inside", PlaytimeForever: 600}, {Name: "Limbo", PlaytimeForever: 599}}, goal: 600, want: "Inside"},
+		{name: "ties by name", games: []Game{{Name: "Limbo", PlaytimeForever: 300}, {Name: "Inside", PlaytimeForever: 300}}, goal: 600, want: "Inside"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getGameClosestToGoal(tt.games, tt.goal)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getGameClosestToGoal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("getGameClosestToGoal() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestPerformOpenIDLoginUsesBrowserOpener(t *testing.T) {
+	var opened string
+	noop := func(uri string) error {
+		opened = uri
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := performOpenIDLogin(ctx, noop, false, false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("performOpenIDLogin() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if !strings.HasPrefix(opened, steamOpenIDURL) || !strings.Contains(opened, "openid.return_to=http%3A%2F%2Flocalhost%3A") {
+		t.Errorf("performOpenIDLogin() opened %q, want the Steam login URL with a localhost callback", opened)
+	}
+
+	opened = ""
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	performOpenIDLogin(ctx, noop, true, false)
+	if opened != "" {
+		t.Errorf("performOpenIDLogin() with noBrowser opened %q, want nothing", opened)
+	}
+}
+
+func TestRemoveDLC(t *testing.T) {
+	games := []Game{
+		{AppID: 1145360, Name: "Hades"},
+		{AppID: 2000, Name: "Soundtrack"},
+		{AppID: 400, Name: "Portal"},
+	}
+	details := map[int]GameDetails{
+		1145360: {AppID: 1145360, Type: "game"},
+		2000:    {AppID: 2000, Type: "dlc"},
+	}
+
+	var names []string
+	for _, game := range removeDLC(games, details) {
+		names = append(names, game.Name)
+	}
+	if want := []string{"Hades", "Portal"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("removeDLC() = %v, want %v", names, want)
+	}
+}
+
+func TestParseFlagsDLC(t *testing.T) {
+	t.Setenv("WSIPN_THRESHOLD", "")
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{args: nil, want: true},
+		{args: []string{"--include-dlc"}, want: false},
+		{args: []string{"--exclude-dlc=false"}, want: false},
+	}
+	for _, tt := range tests {
+		opts, err := parseFlags(tt.args)
+		if err != nil {
+			t.Fatalf("parseFlags(%v) error = %v", tt.args, err)
+		}
+		if opts.excludeDLC != tt.want {
+			t.Errorf("parseFlags(%v) excludeDLC = %v, want %v", tt.args, opts.excludeDLC, tt.want)
+		}
+	}
+}
+
+// sequenceSteamClient returns the next library of Libraries on every call and keeps
+// returning the last one once they run out.
+type sequenceSteamClient struct {
+	Libraries [][]Game
+	calls     int
+}
+
+func (c *sequenceSteamClient) GetOwnedGames(ctx context.Context, steamID64 string) ([]Game, error) {
+	i := c.calls
+	if i >= len(c.Libraries) {
+		i = len(c.Libraries) - 1
+	}
+	c.calls++
+	return c.Libraries[i], nil
+}
+
+func TestWatchNewGames(t *testing.T) {
+	portal := Game{AppID: 400, Name: "Portal"}
+	hades := Game{AppID: 1145360, Name: "Hades"}
+	celeste := Game{AppID: 504230, Name: "Celeste"}
+	client := &sequenceSteamClient{Libraries: [][]Game{
+		{portal},
+		{portal, hades},
+		{portal, hades},
+		{portal, hades, celeste},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var found []string
+	err := watchNewGames(ctx, client, "76561197960287930", time.Millisecond, func(game Game) {
+		found = append(found, game.Name)
+		if len(found) == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("watchNewGames() error = %v, want %v", err, context.Canceled)
+	}
+	if want := []string{"Hades", "Celeste"}; !reflect.DeepEqual(found, want) {
+		t.Errorf("watchNewGames() reported %v, want %v", found, want)
+	}
+}
+
+func TestWatchNewGamesInitialFetchFails(t *testing.T) {
+	wantErr := errors.New("boom")
+	err := watchNewGames(context.Background(), &MockSteamClient{Err: wantErr}, "76561197960287930", time.Millisecond, func(Game) {})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("watchNewGames() error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestIsBetaEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "Counter-Strike 2 Beta", want: true},
+		{name: "Deadlock Playtest", want: true},
+		{name: "Spacewar Test App", want: true},
+		{name: "Hades", want: false},
+		{name: "Alphabet", want: false},
+		{name: "beta", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBetaEntry(tt.name); got != tt.want {
+				t.Errorf("isBetaEntry(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveBetaEntries(t *testing.T) {
+	games := []Game{
+		{Name: "Counter-Strike 2 Beta"},
+		{Name: "Hades"},
+		{Name: "Beta Decay"},
+	}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		want    []string
+	}{
+		{name: "no allowlist", allowed: nil, want: []string{"Hades"}},
+		{name: "allowlist is case-insensitive", allowed: []string{"beta decay"}, want: []string{"Hades", "Beta Decay"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, game := range removeBetaEntries(games, tt.allowed) {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("removeBetaEntries() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	previous := retryBaseDelay
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = previous })
+
+	errTransient := errors.New("transient")
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		failures    int   // calls that fail before the first success
+		failWith    error // the error returned by failing calls
+		wantCalls   int
+		wantErr     error
+	}{
+		{name: "first attempt succeeds", maxAttempts: 4, failures: 0, wantCalls: 1},
+		{name: "succeeds after retries", maxAttempts: 4, failures: 2, failWith: errTransient, wantCalls: 3},
+		{name: "gives up after max attempts", maxAttempts: 3, failures: 10, failWith: errTransient, wantCalls: 3, wantErr: errTransient},
+		{name: "zero attempts still calls once", maxAttempts: 0, failures: 10, failWith: errTransient, wantCalls: 1, wantErr: errTransient},
+		{name: "permanent error stops early", maxAttempts: 4, failures: 10, failWith: permanent(errFatal), wantCalls: 1, wantErr: errFatal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withRetry(context.Background(), tt.maxAttempts, func() error {
+				calls++
+				if calls <= tt.failures {
+					return tt.failWith
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("withRetry() made %d calls, want %d", calls, tt.wantCalls)
+			}
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Errorf("withRetry() error = %v, want %v", err, tt.wantErr)
+			}
+			var perm *permanentError
+			if errors.As(err, &perm) {
+				t.Errorf("withRetry() error = %v, want the permanent wrapper removed", err)
+			}
+		})
+	}
+}
+
+func TestWithRetryBackoff(t *testing.T) {
+	previous := retryBaseDelay
+	retryBaseDelay = 20 * time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = previous })
+
+	start := time.Now()
+	withRetry(context.Background(), 3, func() error { return errors.New("transient") })
+	// Two waits of 20ms and 40ms, each with at most 10% jitter below.
+	if elapsed := time.Since(start); elapsed < 54*time.Millisecond {
+		t.Errorf("3 attempts took %s, want at least 54ms of back-off", elapsed)
+	}
+}
+
+func TestWithRetryContextCancelled(t *testing.T) {
+	previous := retryBaseDelay
+	retryBaseDelay = time.Hour
+	t.Cleanup(func() { retryBaseDelay = previous })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	calls := 0
+	err := withRetry(ctx, 4, func() error {
+		calls++
+		return errors.New("transient")
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "transient") {
+		t.Errorf("withRetry() error = %v, want %v wrapping the last error", err, context.DeadlineExceeded)
+	}
+	if calls != 1 {
+		t.Errorf("withRetry() made %d calls, want 1", calls)
+	}
+}
+
+func TestValidateSteamID64(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{name: "valid", id: "76561197960287930"},
+		{name: "first individual account", id: "76561197960265728"},
+		{name: "last individual account", id: "76561202255233023"},
+		{name: "too short", id: "7656119796028793", wantErr: true},
+		{name: "too long", id: "765611979602879300", wantErr: true},
+		{name: "empty", id: "", wantErr: true},
+		{name: "non-digits", id: "7656119796028793a", wantErr: true},
+		{name: "below the individual range", id: "76561197960265727", wantErr: true},
+		{name: "wrong universe prefix", id: "86561197960287930", wantErr: true},
+		{name: "above the individual range", id: "76561202255233024", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSteamID64(tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("validateSteamID64(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// sampleReport is a small Report shared by the renderer tests.
+func sampleReport() Report {
+	streak := Game{AppID: 620, Name: "Portal 2", PlaytimeForever: 600, PlaytimeTwoWeeks: 90}
+	return Report{
+		TotalGames:      3,
+		Unplayed:        []Game{{AppID: 504230, Name: "Celeste, Deluxe"}},
+		UnplayedPercent: 33.3,
+		PlaytimeUnit:    "hours",
+		TotalPlaytime:   3600,
+		Stats:           PlaytimeStats{Total: 3600, Mean: 1200},
+		LeastPlayed:     Game{AppID: 504230, Name: "Celeste, Deluxe"},
+		MostPlayed:      Game{AppID: 1145360, Name: "Hades", PlaytimeForever: 3000},
+		Streak:          &streak,
+		TopPlayed:       []Game{{AppID: 1145360, Name: "Hades", PlaytimeForever: 3000}},
+		RandomUnplayed:  []Game{{AppID: 504230, Name: "Celeste, Deluxe"}},
+	}
+}
+
+func TestTextRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (TextRenderer{}).Render(&buf, sampleReport()); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Total games: 3, Unplayed games (no playtime recorded): 1",
+		"33.3% of your library is unplayed",
+		"== Most Played Game ==\nHades (50.0h)",
+		"== Keep the Streak ==\nPortal 2 (1.5h in the last two weeks)",
+		"Randomly selected game to play: Celeste, Deluxe",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Render() output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "== Almost There ==") {
+		t.Errorf("Render() printed an empty optional section:\n%s", out)
+	}
+}
+
+func TestJSONRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (JSONRenderer{}).Render(&buf, sampleReport()); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	var got struct {
+		RandomUnplayed []jsonGame `json:"random_unplayed"`
+		MostPlayed     jsonGame   `json:"most_played"`
+		Streak         *jsonGame  `json:"streak"`
+		AlmostThere    *jsonGame  `json:"almost_there"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Render() wrote invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(got.RandomUnplayed) != 1 || got.RandomUnplayed[0].Name != "Celeste, Deluxe" {
+		t.Errorf("random_unplayed = %+v, want Celeste, Deluxe", got.RandomUnplayed)
+	}
+	if got.MostPlayed.AppID != 1145360 || got.MostPlayed.Playtime != "50.0h" {
+		t.Errorf("most_played = %+v, want Hades with playtime 50.0h", got.MostPlayed)
+	}
+	if got.Streak == nil || got.Streak.Name != "Portal 2" {
+		t.Errorf("streak = %+v, want Portal 2", got.Streak)
+	}
+	if got.AlmostThere != nil {
+		t.Errorf("almost_there = %+v, want null", got.AlmostThere)
+	}
+}
+
+func TestCSVRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	report := sampleReport()
+	report.Unplayed = append(report.Unplayed, Game{Name: "Portal", PlaytimeForever: 5})
+	if err := (CSVRenderer{}).Render(&buf, report); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Render() wrote invalid CSV: %v", err)
+	}
+	want := [][]string{
+		{"name", "playtime_minutes"},
+		{"Celeste, Deluxe", "0"},
+		{"Portal", "5"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Render() records = %v, want %v", records, want)
+	}
+}
+
+func TestGetStreakGame(t *testing.T) {
+	tests := []struct {
+		name    string
+		games   []Game
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "most played in two weeks",
+			games: []Game{
+				{Name: "Hades", PlaytimeForever: 3000, PlaytimeTwoWeeks: 30},
+				{Name: "Celeste", PlaytimeForever: 100, PlaytimeTwoWeeks: 90},
+			},
+			want: "Celeste",
+		},
+		{
+			name: "tie broken by total playtime",
+			games: []Game{
+				{Name: "Celeste", PlaytimeForever: 100, PlaytimeTwoWeeks: 60},
+				{Name: "Hades", PlaytimeForever: 3000, PlaytimeTwoWeeks: 60},
+			},
+			want: "Hades",
+		},
+		{
+			name:    "nothing played recently",
+			games:   []Game{{Name: "Hades", PlaytimeForever: 3000}},
+			wantErr: true,
+		},
+		{name: "empty", games: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getStreakGame(tt.games)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getStreakGame() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("getStreakGame() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".wsipn_history")
+
+	if names, err := loadHistory(path, 5); err != nil || len(names) != 0 {
+		t.Fatalf("loadHistory() on a missing file = %v, %v, want empty history", names, err)
+	}
+	for _, name := range []string{"Portal", "Hades", "Celeste"} {
+		if err := appendHistory(path, name, 10); err != nil {
+			t.Fatalf("appendHistory(%q) error = %v", name, err)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("history file mode = %v, want 0600", perm)
+	}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{n: 2, want: []string{"Hades", "Celeste"}},
+		{n: 10, want: []string{"Portal", "Hades", "Celeste"}},
+		{n: 0, want: []string{}},
+		{n: -1, want: []string{}},
+	}
+	for _, tt := range tests {
+		names, err := loadHistory(path, tt.n)
+		if err != nil {
+			t.Fatalf("loadHistory(%d) error = %v", tt.n, err)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("loadHistory(%d) = %v, want %v", tt.n, names, tt.want)
+		}
+	}
+}
+
+func TestGroupByPlaytimeBucket(t *testing.T) {
+	var games []Game
+	for _, minutes := range []int{0, 59, 60, 119, 120, 600, 10000} {
+		games = append(games, Game{Name: strconv.Itoa(minutes), PlaytimeForever: minutes})
+	}
+
+	got := make(map[string][]string)
+	for label, bucket := range groupByPlaytimeBucket(games, 60) {
+		for _, game := range bucket {
+			got[label] = append(got[label], game.Name)
+		}
+	}
+	want := map[string][]string{
+		"0-60min": {"0", "59"},
+		"1h-2h":   {"60", "119"},
+		"2h-5h":   {"120"},
+		"10h+":    {"600", "10000"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByPlaytimeBucket() = %v, want %v", got, want)
+	}
+}
+
+func TestRenderHistogram(t *testing.T) {
+	buckets := map[string][]Game{
+		"short": {{}, {}},
+		"long":  {{}},
+	}
+	tests := []struct {
+		name  string
+		width int
+		want  string
+	}{
+		{name: "bars scale to the width", width: 18, want: "short 2 ████████\nlong  1 ████\nnone  0 \n"},
+		{name: "narrow terminal keeps one column", width: 1, want: "short 2 █\nlong  1 \nnone  0 \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderHistogram(buckets, []string{"short", "long", "none"}, tt.width); got != tt.want {
+				t.Errorf("renderHistogram() =\n%q, want\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCompletionistGame(t *testing.T) {
+	tests := []struct {
+		name    string
+		games   []Game
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "closest to the next full hour",
+			games: []Game{
+				{Name: "Hades", PlaytimeForever: 130},
+				{Name: "Portal", PlaytimeForever: 55},
+				{Name: "Celeste", PlaytimeForever: 0},
+			},
+			want: "Portal",
+		},
+		{
+			name: "exact hour needs a full hour",
+			games: []Game{
+				{Name: "Hades", PlaytimeForever: 120},
+				{Name: "Portal", PlaytimeForever: 30},
+			},
+			want: "Portal",
+		},
+		{
+			name: "tie broken by name",
+			games: []Game{
+				{Name: "Portal", PlaytimeForever: 50},
+				{Name: "Hades", PlaytimeForever: 110},
+			},
+			want: "Hades",
+		},
+		{
+			name:    "nothing started",
+			games:   []Game{{Name: "Celeste", PlaytimeForever: 0}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getCompletionistGame(tt.games)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getCompletionistGame() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("getCompletionistGame() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderSelection(t *testing.T) {
+	games := []Game{
+		{AppID: 400, Name: "Portal", PlaytimeForever: 30},
+		{AppID: 504230, Name: "Celeste"},
+	}
+
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{format: "text", want: "Portal\nCeleste\n"},
+		{format: "csv", want: "name,playtime_minutes\nPortal,30\nCeleste,0\n"},
+		{format: "markdown", want: "| # | Game | Playtime |\n| ---: | --- | ---: |\n| 1 | Portal | 0.5h |\n| 2 | Celeste | 0.0h |\n"},
+		{format: "yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := renderSelection(&buf, games, tt.format, "hours")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderSelection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := buf.String(); !tt.wantErr && got != tt.want {
+				t.Errorf("renderSelection() =\n%q, want\n%q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := renderSelection(&buf, games, "json", "minutes"); err != nil {
+			t.Fatalf("renderSelection() error = %v", err)
+		}
+		var got []jsonGame
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("renderSelection() wrote invalid JSON: %v\n%s", err, buf.String())
+		}
+		if len(got) != 2 || got[0].Name != "Portal" || got[0].Playtime != "30m" || got[1].AppID != 504230 {
+			t.Errorf("renderSelection() = %+v, want Portal (30m) and Celeste", got)
+		}
+	})
+}
+
+func TestGetSteamIDFilePath(t *testing.T) {
+	defer func(old StorageConfig) { storage = old }(storage)
+	base := t.TempDir()
+	storage = StorageConfig{BaseDir: base}
+
+	tests := []struct {
+		profile string
+		wantErr bool
+	}{
+		{profile: "default"},
+		{profile: "work.alt"},
+		{profile: "", wantErr: true},
+		{profile: ".", wantErr: true},
+		{profile: "..", wantErr: true},
+		{profile: "../evil", wantErr: true},
+		{profile: "nested/profile", wantErr: true},
+		{profile: `..\evil`, wantErr: true},
+		{profile: "/etc/passwd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			path, err := getSteamIDFilePath(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getSteamIDFilePath(%q) error = %v, wantErr %v", tt.profile, err, tt.wantErr)
+			}
+			if want := filepath.Join(base, ".wsipn", "profiles", tt.profile); !tt.wantErr && path != want {
+				t.Errorf("getSteamIDFilePath(%q) = %q, want %q", tt.profile, path, want)
+			}
+		})
+	}
+}
+
+func TestGetGamesInRange(t *testing.T) {
+	var games []Game
+	for _, minutes := range []int{0, 29, 30, 299, 300, 1000} {
+		games = append(games, Game{Name: strconv.Itoa(minutes), PlaytimeForever: minutes})
+	}
+
+	tests := []struct {
+		name     string
+		min, max int
+		want     []string
+		wantErr  bool
+	}{
+		{name: "min inclusive, max exclusive", min: 30, max: 300, want: []string{"30", "299"}},
+		{name: "max zero is unbounded", min: 30, max: 0, want: []string{"30", "299", "300", "1000"}},
+		{name: "empty band", min: 30, max: 30, want: []string{}},
+		{name: "whole library", min: 0, max: 0, want: []string{"0", "29", "30", "299", "300", "1000"}},
+		{name: "min above max", min: 300, max: 30, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getGamesInRange(games, tt.min, tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getGamesInRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			names := make([]string, 0, len(got))
+			for _, game := range got {
+				names = append(names, game.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("getGamesInRange(%d, %d) = %v, want %v", tt.min, tt.max, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendHistoryKeepsLastN(t *testing.T) {
+	const keep = 5
+	path := filepath.Join(t.TempDir(), ".wsipn_history")
+
+	var want []string
+	for i := 0; i < keep+5; i++ {
+		name := fmt.Sprintf("Game %d", i)
+		if err := appendHistory(path, name, keep); err != nil {
+			t.Fatalf("appendHistory(%q) error = %v", name, err)
+		}
+		want = append(want, name)
+	}
+	want = want[len(want)-keep:]
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("history file = %q, want %q", got, want)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("history directory has %d files, want no temporary files left behind", len(entries))
+	}
+}
+
+// captureStdout runs fn with os.Stdout redirected and returns what fn wrote to it.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	previous := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = previous }()
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		done <- buf.String()
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestPerformOpenIDLoginKeepsStdoutClean(t *testing.T) {
+	failing := func(string) error { return errors.New("no browser") }
+	for _, noBrowser := range []bool{false, true} {
+		out := captureStdout(t, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			performOpenIDLogin(ctx, failing, noBrowser, false)
+		})
+		if out != "" {
+			t.Errorf("performOpenIDLogin(noBrowser=%v) wrote %q to stdout, want login messages on stderr", noBrowser, out)
+		}
+	}
+}
+
+func TestInfofQuiet(t *testing.T) {
+	defer func(old bool) { quiet = old }(quiet)
+
+	var buf bytes.Buffer
+	quiet = false
+	infof(&buf, "\rFetching store details %d/%d...", 1, 2)
+	if buf.String() != "\rFetching store details 1/2..." {
+		t.Errorf("infof() wrote %q", buf.String())
+	}
+	buf.Reset()
+	quiet = true
+	infof(&buf, "\rFetching store details %d/%d...", 1, 2)
+	if buf.Len() != 0 {
+		t.Errorf("infof() with --quiet wrote %q, want nothing", buf.String())
+	}
+}
+
+func TestRootCommandFlags(t *testing.T) {
+	t.Setenv("WSIPN_THRESHOLD", "")
+	restoreRuntime(t)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "flags before the command", args: []string{"-v", "--no-save", "login"}, wantErr: "cannot be combined with --no-save"},
+		{name: "flags after the command", args: []string{"login", "--no-save"}, wantErr: "cannot be combined with --no-save"},
+		{name: "invalid combination", args: []string{"--no-save", "--diff"}, wantErr: "invalid arguments"},
+		{name: "unexpected argument", args: []string{"login", "extra"}, wantErr: `unexpected argument "extra"`},
+		{name: "unknown flag", args: []string{"--no-such-flag"}, wantErr: "unknown flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newRootCommand()
+			root.SetArgs(tt.args)
+			root.SetOut(io.Discard)
+			root.SetErr(io.Discard)
+			if err := root.Execute(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Execute(%q) error = %v, want it to contain %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRootCommandCompletesFlags(t *testing.T) {
+	root := newRootCommand()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"__complete", "login", "--thr"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "--threshold") {
+		t.Errorf("completion of --thr = %q, want it to offer --threshold", out.String())
+	}
+}